@@ -0,0 +1,90 @@
+package zcam
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const loginEndpoint = "/login"
+
+func isLoginEndpoint(endpoint string) bool {
+	return strings.HasPrefix(endpoint, loginEndpoint)
+}
+
+// Login performs the login handshake with the credentials provided with
+// WithCredentials, required by the newer firmwares before accepting any
+// command. The session cookie is kept in the Client's cookie jar.
+//
+// There is no need to call it explicitly, the requests rejected as
+// unauthorized log in and are repeated transparently. A rejected login
+// returns an error matching ErrInvalidCredentials.
+func (c *Camera) Login(ctx context.Context) error {
+	if c.username == "" {
+		return ErrUnauthorized
+	}
+
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+
+	return c.login(ctx)
+}
+
+// relogin logs in after a request rejected as unauthorized, started at the
+// given login generation. If another request logged in meanwhile, the login
+// is skipped and the request can be repeated with the new session.
+func (c *Camera) relogin(ctx context.Context, gen uint64) error {
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+
+	if c.loginGen.Load() != gen {
+		return nil
+	}
+
+	return c.login(ctx)
+}
+
+// login performs the login request, loginMu must be held. The login
+// generation is increased on success.
+func (c *Camera) login(ctx context.Context) error {
+	// the credentials are sent as a form, keeping them out of the URL and
+	// so out of any error message.
+	form := url.Values{}
+	form.Set("user", c.username)
+	form.Set("pswd", c.password)
+
+	url := fmt.Sprintf("%s%s", c.baseURL, loginEndpoint)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("unable to create login request: %w", err)
+	}
+
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c.setHeaders(request)
+
+	resp, err := c.Client.Do(request)
+	if err != nil {
+		return fmt.Errorf("error making login request to %s: %w", url, err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &APIError{Endpoint: loginEndpoint, HTTPStatus: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %w", err)
+	}
+
+	if err := decodeBasicRequest(loginEndpoint, body); err != nil {
+		return err
+	}
+
+	c.loginGen.Add(1)
+	return nil
+}
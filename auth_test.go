@@ -0,0 +1,132 @@
+package zcam
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mcuadros/go-zcam-e2/zcamtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogin(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	srv.Camera.SetCredentials("admin", "secret")
+	cli := NewCamera(srv.Listener.Addr().String(), WithCredentials("admin", "secret"))
+
+	ctx := context.Background()
+	require.NoError(t, cli.Login(ctx))
+	require.NoError(t, cli.StartSession(ctx))
+	require.True(t, srv.Camera.HasSession())
+}
+
+func TestLoginTransparent(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	srv.Camera.SetCredentials("admin", "secret")
+	cli := NewCamera(srv.Listener.Addr().String(), WithCredentials("admin", "secret"))
+
+	ctx := context.Background()
+	_, err := cli.QueryTemperature(ctx)
+	require.NoError(t, err)
+
+	srv.Camera.ExpireSessions()
+	_, err = cli.QueryTemperature(ctx)
+	require.NoError(t, err)
+}
+
+func TestLoginConcurrent(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	srv.Camera.SetCredentials("admin", "secret")
+	srv.Faults.Add(zcamtest.Fault{Endpoint: loginEndpoint, Latency: 50 * time.Millisecond})
+
+	var logins atomic.Int32
+	cli := NewCamera(srv.Listener.Addr().String(), WithCredentials("admin", "secret"))
+	cli.Client.Transport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path == loginEndpoint {
+			logins.Add(1)
+		}
+
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	ctx := context.Background()
+	_, err := cli.QueryTemperature(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), logins.Load())
+
+	srv.Camera.ExpireSessions()
+
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = cli.QueryTemperature(ctx)
+		}(i)
+	}
+
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, int32(2), logins.Load())
+}
+
+func TestLoginCredentialsOnlyInForm(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	var authorized int
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Header.Get("Authorization") != "" {
+			authorized++
+		}
+
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	srv.Camera.SetCredentials("admin", "secret")
+	cli := NewCamera(srv.Listener.Addr().String(),
+		WithCredentials("admin", "secret"),
+		WithTransport(transport),
+	)
+
+	_, err := cli.QueryTemperature(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, authorized)
+}
+
+func TestLoginInvalidCredentials(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	srv.Camera.SetCredentials("admin", "secret")
+	cli := NewCamera(srv.Listener.Addr().String(), WithCredentials("admin", "wrong"))
+
+	_, err := cli.QueryTemperature(context.Background())
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	require.NotContains(t, err.Error(), "wrong")
+}
+
+func TestLoginWithoutCredentials(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	srv.Camera.SetCredentials("admin", "secret")
+	cli := NewCamera(srv.Listener.Addr().String())
+
+	_, err := cli.QueryTemperature(context.Background())
+	require.ErrorIs(t, err, ErrUnauthorized)
+	require.ErrorIs(t, cli.Login(context.Background()), ErrUnauthorized)
+}
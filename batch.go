@@ -0,0 +1,60 @@
+package zcam
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+)
+
+const defaultConcurrency = 4
+
+// GetSettings retrieves several settings, making at most Concurrency
+// requests at the same time. The values are returned by setting, and the
+// settings failing to be read are returned in the second map with their
+// error, so a failing setting doesn't discard the rest. If the context is
+// done, the pending settings fail with the context error.
+func (c *Camera) GetSettings(ctx context.Context, keys ...settings.Setting) (map[settings.Setting]*SettingValue, map[settings.Setting]error) {
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		values = make(map[settings.Setting]*SettingValue, len(keys))
+		errs   = make(map[settings.Setting]error)
+	)
+
+	sem := make(chan struct{}, concurrency)
+	for _, key := range keys {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			errs[key] = ctx.Err()
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(key settings.Setting) {
+			defer func() { <-sem; wg.Done() }()
+
+			v, err := c.GetSetting(ctx, key)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[key] = err
+				return
+			}
+
+			values[key] = v
+		}(key)
+	}
+
+	wg.Wait()
+	return values, errs
+}
@@ -0,0 +1,70 @@
+package zcam
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+	"github.com/mcuadros/go-zcam-e2/zcamtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSettings(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/ctrl/get", Latency: 10 * time.Millisecond})
+
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	cli := NewCamera(srv.Listener.Addr().String(), WithTransport(transport), WithConcurrency(2))
+	values, errs := cli.GetSettings(context.Background(),
+		settings.ISOSetting,
+		settings.MWBSetting,
+		settings.BatterySetting,
+		settings.LEDSetting,
+		settings.VignetteSetting,
+	)
+
+	require.Len(t, values, 4)
+	require.Equal(t, "iso", values[settings.ISOSetting].Key)
+	require.Equal(t, "led", values[settings.LEDSetting].Key)
+
+	require.Len(t, errs, 1)
+	require.ErrorIs(t, errs[settings.VignetteSetting], ErrNotSupported)
+	require.Equal(t, 2, maxInFlight)
+}
+
+func TestGetSettingsCanceled(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	values, errs := cli.GetSettings(ctx, settings.ISOSetting, settings.LEDSetting)
+	require.Empty(t, values)
+	require.Len(t, errs, 2)
+	require.ErrorIs(t, errs[settings.ISOSetting], context.Canceled)
+}
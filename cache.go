@@ -0,0 +1,132 @@
+package zcam
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+)
+
+// CachePolicy configures the cache of the values read by GetSetting. The
+// cached value of a setting, and of the settings the camera may change along
+// with it, is dropped when it's changed by SetSetting.
+type CachePolicy struct {
+	// TTL is how long the values are cached, zero disables the cache.
+	TTL time.Duration
+	// ReadOnlyTTL is used for the read-only settings, such as the battery or
+	// the live exposure values, changing without being set.
+	ReadOnlyTTL time.Duration
+	// Settings overrides the TTL of the given settings, zero disables the
+	// cache of the setting.
+	Settings map[settings.Setting]time.Duration
+}
+
+// DefaultCachePolicy returns a CachePolicy caching the values for 1 minute,
+// and the read-only values for 1 second.
+func DefaultCachePolicy() *CachePolicy {
+	return &CachePolicy{
+		TTL:         time.Minute,
+		ReadOnlyTTL: time.Second,
+	}
+}
+
+// uncachedSettings are never cached unless set at CachePolicy.Settings,
+// since they change on every recording or still.
+var uncachedSettings = map[settings.Setting]bool{
+	settings.RecDurationSetting:  true,
+	settings.LastFileNameSetting: true,
+}
+
+func (p *CachePolicy) ttl(key settings.Setting, v *SettingValue) time.Duration {
+	if ttl, ok := p.Settings[key]; ok {
+		return ttl
+	}
+
+	if uncachedSettings[key] {
+		return 0
+	}
+
+	if v.ReadOnly {
+		return p.ReadOnlyTTL
+	}
+
+	return p.TTL
+}
+
+type cachedValue struct {
+	value   SettingValue
+	expires time.Time
+}
+
+// valueCache holds the values read by GetSetting. The generation is
+// increased on every invalidation, so a read started before it is not
+// cached.
+type valueCache struct {
+	mu     sync.Mutex
+	values map[settings.Setting]cachedValue
+	gen    uint64
+}
+
+func (c *valueCache) get(key settings.Setting) (*SettingValue, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cv, ok := c.values[key]
+	if !ok || !time.Now().Before(cv.expires) {
+		return nil, false
+	}
+
+	v := cv.value
+	return &v, true
+}
+
+func (c *valueCache) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+func (c *valueCache) set(key settings.Setting, v *SettingValue, ttl time.Duration, gen uint64) {
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.gen != gen {
+		return
+	}
+
+	if c.values == nil {
+		c.values = make(map[settings.Setting]cachedValue)
+	}
+
+	c.values[key] = cachedValue{value: *v, expires: time.Now().Add(ttl)}
+}
+
+// invalidate drops the value of the key and of its dependents.
+func (c *valueCache) invalidate(key settings.Setting) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	for _, k := range withDependents(key) {
+		delete(c.values, k)
+	}
+}
+
+func (c *valueCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	c.values = nil
+}
+
+// ResetSettingsCache drops the values cached by GetSetting, it should be
+// called if the settings may have been changed by other means, such as from
+// the camera menus.
+func (c *Camera) ResetSettingsCache() {
+	c.values.reset()
+}
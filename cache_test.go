@@ -0,0 +1,138 @@
+package zcam
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+	"github.com/mcuadros/go-zcam-e2/zcamtest"
+	"github.com/stretchr/testify/require"
+)
+
+func newCountingCamera(srv *zcamtest.Server, opts ...Option) (*Camera, func(string) int) {
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		requests[r.URL.RequestURI()]++
+		mu.Unlock()
+
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	cli := NewCamera(srv.Listener.Addr().String(), append(opts, WithTransport(transport))...)
+	return cli, func(uri string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests[uri]
+	}
+}
+
+func TestGetSettingCache(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	cli, count := newCountingCamera(srv, WithCache(&CachePolicy{
+		TTL:         time.Minute,
+		ReadOnlyTTL: 50 * time.Millisecond,
+		Settings:    map[settings.Setting]time.Duration{settings.LEDSetting: 0},
+	}))
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		v, err := cli.GetSetting(ctx, settings.ISOSetting)
+		require.NoError(t, err)
+		require.Equal(t, "iso", v.Key)
+
+		_, err = cli.GetSetting(ctx, settings.BatterySetting)
+		require.NoError(t, err)
+
+		_, err = cli.GetSetting(ctx, settings.LEDSetting)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, 1, count("/ctrl/get?k=iso"))
+	require.Equal(t, 1, count("/ctrl/get?k=battery"))
+	require.Equal(t, 3, count("/ctrl/get?k=led"))
+
+	time.Sleep(60 * time.Millisecond)
+	_, err := cli.GetSetting(ctx, settings.BatterySetting)
+	require.NoError(t, err)
+	_, err = cli.GetSetting(ctx, settings.ISOSetting)
+	require.NoError(t, err)
+	require.Equal(t, 2, count("/ctrl/get?k=battery"))
+	require.Equal(t, 1, count("/ctrl/get?k=iso"))
+
+	cli.ResetSettingsCache()
+	_, err = cli.GetSetting(ctx, settings.ISOSetting)
+	require.NoError(t, err)
+	require.Equal(t, 2, count("/ctrl/get?k=iso"))
+}
+
+func TestGetSettingCacheInvalidation(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	cli, count := newCountingCamera(srv, WithCache(DefaultCachePolicy()))
+	ctx := context.Background()
+
+	keys := []settings.Setting{
+		settings.ISOSetting,
+		settings.VideoSystemSetting,
+		settings.MovFmtSetting,
+		settings.ResolutionSetting,
+		settings.RecFPSSetting,
+	}
+
+	for _, key := range keys {
+		_, err := cli.GetSetting(ctx, key)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, cli.SetSetting(ctx, settings.ISOSetting, 800))
+	v, err := cli.GetSetting(ctx, settings.ISOSetting)
+	require.NoError(t, err)
+	require.Equal(t, "800", v.Value)
+	require.Equal(t, 2, count("/ctrl/get?k=iso"))
+
+	require.NoError(t, cli.SetSetting(ctx, settings.VideoSystemSetting, "NTSC"))
+	for _, key := range keys {
+		_, err := cli.GetSetting(ctx, key)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, 2, count("/ctrl/get?k=iso"))
+	require.Equal(t, 2, count("/ctrl/get?k=movfmt"))
+	require.Equal(t, 2, count("/ctrl/get?k=resolution"))
+	require.Equal(t, 2, count("/ctrl/get?k=rec_fps"))
+}
+
+func TestGetSettingCacheLensInvalidation(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	cli := NewCamera(srv.Listener.Addr().String(), WithCache(DefaultCachePolicy()))
+	ctx := context.Background()
+
+	_, err := cli.GetSetting(ctx, settings.LensFocusPosSetting)
+	require.NoError(t, err)
+	_, err = cli.GetSetting(ctx, settings.LensZoomPosSetting)
+	require.NoError(t, err)
+
+	require.NoError(t, cli.SetLensFocusPosition(ctx, 500))
+	v, err := cli.GetSetting(ctx, settings.LensFocusPosSetting)
+	require.NoError(t, err)
+	pos, err := v.Int()
+	require.NoError(t, err)
+	require.Equal(t, 500, pos)
+
+	require.NoError(t, cli.SetZoomPosition(ctx, 50))
+	v, err = cli.GetSetting(ctx, settings.LensZoomPosSetting)
+	require.NoError(t, err)
+	pos, err = v.Int()
+	require.NoError(t, err)
+	require.Equal(t, 50, pos)
+}
@@ -0,0 +1,149 @@
+package zcam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+)
+
+// SettingSchema describes a setting supported by a camera.
+type SettingSchema struct {
+	Key      settings.Setting `json:"key"`
+	Type     SettingType      `json:"type"`
+	ReadOnly bool             `json:"read_only,omitempty"`
+	Options  []string         `json:"options,omitempty"` // Only for choice type
+	Min      int              `json:"min,omitempty"`     // Only for range type
+	Max      int              `json:"max,omitempty"`     // Only for range type
+	Step     int              `json:"step,omitempty"`    // Only for range type
+}
+
+func newSettingSchema(key settings.Setting, v *SettingValue) SettingSchema {
+	return SettingSchema{
+		Key:      key,
+		Type:     v.Type,
+		ReadOnly: bool(v.ReadOnly),
+		Options:  v.Options,
+		Min:      v.Min,
+		Max:      v.Max,
+		Step:     v.Step,
+	}
+}
+
+func (s *SettingSchema) settingValue() *SettingValue {
+	return &SettingValue{
+		Key:      string(s.Key),
+		Type:     s.Type,
+		ReadOnly: ReadOnly(s.ReadOnly),
+		Options:  s.Options,
+		Min:      s.Min,
+		Max:      s.Max,
+		Step:     s.Step,
+	}
+}
+
+// Capabilities describes the settings supported by a camera model and
+// firmware version.
+type Capabilities struct {
+	Model    string `json:"model"`
+	Firmware string `json:"firmware"`
+	// Settings are the supported settings, in the order of settings.All.
+	Settings []SettingSchema `json:"settings"`
+	// Unsupported are the known settings not supported by the camera, the
+	// ones GetSetting reports with ErrNotSupported.
+	Unsupported []settings.Setting `json:"unsupported,omitempty"`
+}
+
+// Setting returns the schema of the given setting, false if it's not
+// supported.
+func (c *Capabilities) Setting(key settings.Setting) (*SettingSchema, bool) {
+	for i := range c.Settings {
+		if c.Settings[i].Key == key {
+			return &c.Settings[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// Supports returns true if the given setting is supported.
+func (c *Capabilities) Supports(key settings.Setting) bool {
+	_, ok := c.Setting(key)
+	return ok
+}
+
+// Validate checks if the value is accepted by the setting, as
+// Camera.ValidateSetting does without requesting the camera. An error
+// matching ErrNotSupported is returned if the setting is not supported.
+func (c *Capabilities) Validate(key settings.Setting, value any) error {
+	s, ok := c.Setting(key)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotSupported, key)
+	}
+
+	str, err := convertToString(value)
+	if err != nil {
+		return &ValidationError{Key: key, Value: fmt.Sprint(value), Setting: s.settingValue(), Err: err}
+	}
+
+	return validateValue(key, s.settingValue(), str)
+}
+
+var capabilitiesCache struct {
+	sync.Mutex
+	m map[string]*Capabilities
+}
+
+// ResetCapabilitiesCache drops the capabilities cached by Capabilities.
+func ResetCapabilitiesCache() {
+	capabilitiesCache.Lock()
+	defer capabilitiesCache.Unlock()
+	capabilitiesCache.m = nil
+}
+
+// Capabilities probes every known setting and returns the schemas of the ones
+// supported by the camera. The result is cached by model and firmware
+// version, shared by all the cameras, and it must not be modified. If any
+// setting fails for another reason, the error is returned and nothing is
+// cached, so a transient failure doesn't hide a setting.
+func (c *Camera) Capabilities(ctx context.Context) (*Capabilities, error) {
+	info, err := c.GetCameraInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key := info.Model + "/" + info.Sw
+
+	capabilitiesCache.Lock()
+	caps, ok := capabilitiesCache.m[key]
+	capabilitiesCache.Unlock()
+	if ok {
+		return caps, nil
+	}
+
+	caps = &Capabilities{Model: info.Model, Firmware: info.Sw}
+	for _, k := range settings.All {
+		v, err := c.GetSetting(ctx, k)
+		if errors.Is(err, ErrNotSupported) {
+			caps.Unsupported = append(caps.Unsupported, k)
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("error probing %s: %w", k, err)
+		}
+
+		caps.Settings = append(caps.Settings, newSettingSchema(k, v))
+	}
+
+	capabilitiesCache.Lock()
+	defer capabilitiesCache.Unlock()
+	if capabilitiesCache.m == nil {
+		capabilitiesCache.m = make(map[string]*Capabilities)
+	}
+
+	capabilitiesCache.m[key] = caps
+	return caps, nil
+}
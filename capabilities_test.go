@@ -0,0 +1,107 @@
+package zcam
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+	"github.com/mcuadros/go-zcam-e2/zcamtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilities(t *testing.T) {
+	defer ResetCapabilitiesCache()
+
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	info := srv.Camera.Info()
+	info.Sw = "test-capabilities"
+	srv.Camera.SetInfo(info)
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	caps, err := cli.Capabilities(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, info.Model, caps.Model)
+	require.Equal(t, "test-capabilities", caps.Firmware)
+
+	iso, ok := caps.Setting(settings.ISOSetting)
+	require.True(t, ok)
+	require.Equal(t, ChoiceSettingType, iso.Type)
+	require.Contains(t, iso.Options, "800")
+
+	mwb, ok := caps.Setting(settings.MWBSetting)
+	require.True(t, ok)
+	require.Equal(t, SettingSchema{
+		Key: settings.MWBSetting, Type: RangeSettingType, Min: 2300, Max: 10000, Step: 100,
+	}, *mwb)
+
+	battery, ok := caps.Setting(settings.BatterySetting)
+	require.True(t, ok)
+	require.True(t, battery.ReadOnly)
+
+	require.False(t, caps.Supports(settings.VignetteSetting))
+	require.Contains(t, caps.Unsupported, settings.VignetteSetting)
+	require.Contains(t, caps.Unsupported, settings.PhotoSizeSetting)
+
+	require.NoError(t, caps.Validate(settings.ISOSetting, 800))
+	require.ErrorIs(t, caps.Validate(settings.ISOSetting, 801), ErrInvalidValue)
+	require.ErrorIs(t, caps.Validate(settings.BatterySetting, 10), ErrReadOnlySetting)
+	require.ErrorIs(t, caps.Validate(settings.VignetteSetting, "On"), ErrNotSupported)
+}
+
+func TestCapabilitiesCache(t *testing.T) {
+	defer ResetCapabilitiesCache()
+
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	info := srv.Camera.Info()
+	info.Sw = "test-capabilities-cache"
+	srv.Camera.SetInfo(info)
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	ctx := context.Background()
+
+	caps, err := cli.Capabilities(ctx)
+	require.NoError(t, err)
+	require.True(t, caps.Supports(settings.LEDSetting))
+
+	srv.Camera.RemoveSetting(settings.LEDSetting)
+	cached, err := cli.Capabilities(ctx)
+	require.NoError(t, err)
+	require.Same(t, caps, cached)
+
+	info.Sw = "test-capabilities-cache-upgraded"
+	srv.Camera.SetInfo(info)
+
+	caps, err = cli.Capabilities(ctx)
+	require.NoError(t, err)
+	require.False(t, caps.Supports(settings.LEDSetting))
+}
+
+func TestCapabilitiesTransientFailure(t *testing.T) {
+	defer ResetCapabilitiesCache()
+
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	info := srv.Camera.Info()
+	info.Sw = "test-capabilities-transient"
+	srv.Camera.SetInfo(info)
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	ctx := context.Background()
+
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/ctrl/get?k=iso", StatusCode: http.StatusInternalServerError, Count: 1})
+	_, err := cli.Capabilities(ctx)
+	require.ErrorContains(t, err, "error probing iso")
+
+	// a single rejection is not taken as unsupported
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/ctrl/get?k=iso", Code: -1, Count: 1})
+	caps, err := cli.Capabilities(ctx)
+	require.NoError(t, err)
+	require.True(t, caps.Supports(settings.ISOSetting))
+	require.NotContains(t, caps.Unsupported, settings.ISOSetting)
+}
@@ -6,34 +6,30 @@ import (
 	"strconv"
 )
 
-type cardManagementResponse struct {
-	Code int    `json:"code"`
-	Desc string `json:"desc"`
-	Msg  string `json:"msg"`
-}
-
-// CheckCardPresence checks if a storage card is present in the camera
+// CheckCardPresence checks if a storage card is present in the camera, the
+// camera responds with a non-zero code if there is none.
 func (c *Camera) CheckCardPresence(ctx context.Context) (bool, error) {
-	r, err := c.sendCardRequest(ctx, "/ctrl/card?action=present")
+	endpoint := "/ctrl/card?action=present"
+	body, err := c.get(ctx, endpoint)
+	if err == nil {
+		err = decodeBasicRequest(endpoint, body)
+	}
+
+	if isRejected(err) {
+		return false, nil
+	}
+
 	if err != nil {
 		return false, err
 	}
 
-	return r.Code == 0, nil
+	return true, nil
 }
 
 // FormatCard formats the storage card based on its capacity
 func (c *Camera) FormatCard(ctx context.Context) error {
-	r, err := c.sendCardRequest(ctx, "/ctrl/card?action=format")
-	if err != nil {
-		return err
-	}
-
-	if r.Code != 0 {
-		return fmt.Errorf("unexpected code %d", r.Code)
-	}
-
-	return nil
+	_, err := c.sendCardRequest(ctx, "/ctrl/card?action=format")
+	return err
 }
 
 // FormatCardAs formats the card specifically to either 'fat32' or 'exfat'
@@ -43,16 +39,8 @@ func (c *Camera) FormatCardAs(ctx context.Context, fileSystem string) error {
 	}
 
 	endpoint := fmt.Sprintf("/ctrl/card?action=%s", fileSystem)
-	r, err := c.sendCardRequest(ctx, endpoint)
-	if err != nil {
-		return err
-	}
-
-	if r.Code != 0 {
-		return fmt.Errorf("unexpected code %d", r.Code)
-	}
-
-	return nil
+	_, err := c.sendCardRequest(ctx, endpoint)
+	return err
 }
 
 // QueryCardFreeSpace queries the free space on the card
@@ -80,15 +68,25 @@ func (c *Camera) queryCardSpace(ctx context.Context, action string) (int, error)
 }
 
 // sendCardRequest sends a GET request to the card management endpoints and parses the response
-func (c *Camera) sendCardRequest(ctx context.Context, endpoint string) (*cardManagementResponse, error) {
+func (c *Camera) sendCardRequest(ctx context.Context, endpoint string) (*basicResponse, error) {
 	body, err := c.get(ctx, endpoint)
+	if isRejected(err) {
+		return nil, c.cardError(ctx, err)
+	}
+
 	if err != nil {
 		return nil, err
 	}
 
-	var response cardManagementResponse
-	if err := decodeJSON(body, &response); err != nil {
-		return nil, err
+	return decodeBasicResponse(endpoint, body)
+}
+
+// cardError returns the error of a rejected command, matching ErrNoCard if
+// the camera confirms that there is no card.
+func (c *Camera) cardError(ctx context.Context, err error) error {
+	if present, perr := c.CheckCardPresence(ctx); perr == nil && !present {
+		return fmt.Errorf("%w: %w", ErrNoCard, err)
 	}
-	return &response, nil
+
+	return err
 }
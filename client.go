@@ -4,9 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net"
 	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,35 +18,93 @@ import (
 type Camera struct {
 	baseURL string
 	Client  *http.Client
+	// Retry is the policy used to retry the failed requests, nil disables
+	// the retries.
+	Retry *RetryPolicy
+	// Validate enables the client-side validation of SetSetting, see
+	// ValidateSetting.
+	Validate bool
+	// Concurrency is the maximum number of concurrent requests made by
+	// GetSettings, by default 4.
+	Concurrency int
+	// Cache is the policy used to cache the values read by GetSetting, nil
+	// disables the cache.
+	Cache *CachePolicy
+
+	userAgent          string
+	username, password string
+	basicAuth          *url.Userinfo
+	loginMu            sync.Mutex
+	schemas            schemaCache
+	values             valueCache
+
+	// loginGen is increased on every login, to know if the session was
+	// refreshed after a request was sent.
+	loginGen atomic.Uint64
 }
 
-// NewCamera initializes and returns a CameraClient
-func NewCamera(ip string) *Camera {
+// NewCamera initializes and returns a Camera for the given host, being an IP
+// address, an IPv6 literal or a hostname, optionally including the port.
+func NewCamera(host string, opts ...Option) *Camera {
+	cfg := &config{scheme: "http"}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	// the jar keeps the session cookie set at login, cookiejar.New never
+	// fails without options.
+	jar, _ := cookiejar.New(nil)
+
 	return &Camera{
-		baseURL: fmt.Sprintf("http://%s", ip),
-		Client:  &http.Client{},
+		baseURL: buildBaseURL(host, cfg),
+		Client: &http.Client{
+			Timeout:   cfg.timeout,
+			Transport: cfg.transport,
+			Jar:       jar,
+		},
+		Retry:       cfg.retry,
+		Validate:    cfg.validate,
+		Concurrency: cfg.concurrency,
+		Cache:       cfg.cache,
+		userAgent:   cfg.userAgent,
+		username:    cfg.username,
+		password:    cfg.password,
+		basicAuth:   cfg.basicAuth,
 	}
 }
 
-// get performs a GET request to the given endpoint and returns the response body or an error
+// get performs a GET request to the given endpoint and returns the response
+// body or an error, the request is retried according to the RetryPolicy. A
+// body with a non-zero code is returned as an *APIError, with or without
+// retries.
 func (c *Camera) get(ctx context.Context, endpoint string) ([]byte, error) {
-	url := fmt.Sprintf("%s%s", c.baseURL, endpoint)
-	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	var body []byte
+	err := c.retry(ctx, endpoint, func() error {
+		var err error
+		body, err = c.doGet(ctx, endpoint)
+		if err != nil {
+			return err
+		}
+
+		return peekError(endpoint, body)
+	})
+
 	if err != nil {
-		return nil, fmt.Errorf("unable to create GET request: %w", err)
+		return nil, err
 	}
 
-	resp, err := c.Client.Do(request)
+	return body, nil
+}
+
+func (c *Camera) doGet(ctx context.Context, endpoint string) ([]byte, error) {
+	resp, err := c.do(ctx, endpoint)
 	if err != nil {
-		return nil, fmt.Errorf("error making GET request to %s: %w", url, err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected response code: %d", resp.StatusCode)
-	}
+	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("error reading response body: %w", err)
 	}
@@ -50,6 +112,88 @@ func (c *Camera) get(ctx context.Context, endpoint string) ([]byte, error) {
 	return body, nil
 }
 
+// do performs a GET request to the given endpoint, returning an *APIError if
+// the response status code is not 200. If the camera requires to log in, it
+// logs in with the credentials and the request is repeated, the concurrent
+// requests rejected at the same time share a single login.
+func (c *Camera) do(ctx context.Context, endpoint string) (*http.Response, error) {
+	return c.doMethod(ctx, http.MethodGet, endpoint, nil)
+}
+
+// doMethod performs a request as do does, with the given method and headers.
+// If the request has a Range header, the 206 status code is also accepted.
+func (c *Camera) doMethod(ctx context.Context, method, endpoint string, header http.Header) (*http.Response, error) {
+	gen := c.loginGen.Load()
+	resp, err := c.doRequest(ctx, method, endpoint, header)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && c.username != "" && !isLoginEndpoint(endpoint) {
+		resp.Body.Close()
+		if err := c.relogin(ctx, gen); err != nil {
+			return nil, err
+		}
+
+		resp, err = c.doRequest(ctx, method, endpoint, header)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	partial := resp.StatusCode == http.StatusPartialContent && header.Get("Range") != ""
+	if resp.StatusCode != http.StatusOK && !partial {
+		resp.Body.Close()
+		return nil, &APIError{Endpoint: endpoint, HTTPStatus: resp.StatusCode}
+	}
+
+	return resp, nil
+}
+
+func (c *Camera) doRequest(ctx context.Context, method, endpoint string, header http.Header) (*http.Response, error) {
+	url := fmt.Sprintf("%s%s", c.baseURL, endpoint)
+	request, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create %s request: %w", method, err)
+	}
+
+	for k, v := range header {
+		request.Header[k] = v
+	}
+
+	c.setHeaders(request)
+
+	resp, err := c.Client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("error making %s request to %s: %w", method, url, err)
+	}
+
+	return resp, nil
+}
+
+// setHeaders sets the User-Agent and the basic authentication, if any.
+func (c *Camera) setHeaders(request *http.Request) {
+	if c.userAgent != "" {
+		request.Header.Set("User-Agent", c.userAgent)
+	}
+
+	if c.basicAuth != nil {
+		password, _ := c.basicAuth.Password()
+		request.SetBasicAuth(c.basicAuth.Username(), password)
+	}
+}
+
+// peekError returns the *APIError of a body with a non-zero code, if the
+// body is not a JSON object nil is returned.
+func peekError(endpoint string, body []byte) error {
+	var r basicResponse
+	if json.Unmarshal(body, &r) != nil {
+		return nil
+	}
+
+	return r.err(endpoint)
+}
+
 func decodeJSON(data []byte, v interface{}) error {
 	if err := json.Unmarshal(data, v); err != nil {
 		return fmt.Errorf("error decoding JSON response: %w", err)
@@ -57,21 +201,44 @@ func decodeJSON(data []byte, v interface{}) error {
 	return nil
 }
 
-func decodeBasicRequest(data []byte) error {
-	var r struct {
-		Code int    `json:"code"`
-		Msg  string `json:"msg"`
+// basicResponse is the common body returned by most of the commands.
+type basicResponse struct {
+	Code int    `json:"code"`
+	Desc string `json:"desc"`
+	Msg  string `json:"msg"`
+}
+
+// err returns an *APIError if the code is not zero.
+func (r *basicResponse) err(endpoint string) error {
+	if r.Code == 0 {
+		return nil
 	}
 
+	return &APIError{
+		Endpoint:   endpoint,
+		HTTPStatus: http.StatusOK,
+		Code:       r.Code,
+		Desc:       r.Desc,
+		Msg:        r.Msg,
+	}
+}
+
+func decodeBasicRequest(endpoint string, data []byte) error {
+	_, err := decodeBasicResponse(endpoint, data)
+	return err
+}
+
+func decodeBasicResponse(endpoint string, data []byte) (*basicResponse, error) {
+	var r basicResponse
 	if err := decodeJSON(data, &r); err != nil {
-		return err
+		return nil, err
 	}
 
-	if r.Code != 0 {
-		return fmt.Errorf("unexpected code %d", r.Code)
+	if err := r.err(endpoint); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return &r, nil
 }
 
 // CameraInfo struct models the camera information returned from the /info endpoint
@@ -101,79 +268,28 @@ func (c *Camera) GetCameraInfo(ctx context.Context) (*CameraInfo, error) {
 
 // StartSession starts a control session with the camera
 func (c *Camera) StartSession(ctx context.Context) error {
-	body, err := c.get(ctx, "/ctrl/session")
-	if err != nil {
-		return err
-	}
-
-	return decodeBasicRequest(body)
+	return c.sendControlRequest(ctx, "/ctrl/session")
 }
 
 // QuitSession ends the control session with the camera
 func (c *Camera) QuitSession(ctx context.Context) error {
-	body, err := c.get(ctx, "/ctrl/session?action=quit")
-	if err != nil {
-		return err
-	}
-
-	return decodeBasicRequest(body)
+	return c.sendControlRequest(ctx, "/ctrl/session?action=quit")
 }
 
 // SyncDateTime synchronizes the camera's date and time with the current system time
 func (c *Camera) SyncDateTime(ctx context.Context, dateTime time.Time) error {
 	endpoint := fmt.Sprintf("/datetime?date=%s&time=%s", dateTime.Format("2006-01-02"), dateTime.Format("15:04:05"))
-	body, err := c.get(ctx, endpoint)
-	if err != nil {
-		return err
-	}
-
-	return decodeBasicRequest(body)
+	return c.sendControlRequest(ctx, endpoint)
 }
 
 // ShutdownSystem sends a shutdown command to the camera
 func (c *Camera) ShutdownSystem(ctx context.Context) error {
-	body, err := c.get(ctx, "/ctrl/shutdown")
-	if err != nil {
-		return err
-	}
-
-	return decodeBasicRequest(body)
+	return c.sendControlRequest(ctx, "/ctrl/shutdown")
 }
 
 // RebootSystem sends a reboot command to the camera
 func (c *Camera) RebootSystem(ctx context.Context) error {
-	body, err := c.get(ctx, "/ctrl/reboot")
-	if err != nil {
-		return err
-	}
-
-	return decodeBasicRequest(body)
-}
-
-type WorkingMode string
-
-// Camera mode constants
-const (
-	// VideoRecordWorkingMode video record mode
-	VideoRecordWorkingMode = "rec"
-	// PlaybackWorkingMode playback mode
-	PlaybackWorkingMode = "pb"
-	// StandbyWorkingMode standby mode
-	StandbyWorkingMode = "standby"
-	// ExitStandbyWorkingMode exit_standby
-	ExitStandbyWorkingMode = "exit_standby"
-	// VideoRecordTimeLapseWorkingMode video timelapse record
-	VideoRecordTimeLapseWorkingMode = "rec_tl"
-)
-
-// ChangeWorkingMode switches the camera's working mode based on the provided constant
-func (c *Camera) ChangeWorkingMode(ctx context.Context, mode WorkingMode) (string, error) {
-	body, err := c.get(ctx, fmt.Sprintf("/ctrl/mode?action=%s", mode))
-	if err != nil {
-		return "", err
-	}
-
-	return string(body), nil
+	return c.sendControlRequest(ctx, "/ctrl/reboot")
 }
 
 // NetworkInfoResponse and NetworkConfigResponse to parse responses from network queries
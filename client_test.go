@@ -8,13 +8,29 @@ import (
 	"testing"
 	"time"
 
+	"github.com/mcuadros/go-zcam-e2/zcamtest"
 	"github.com/stretchr/testify/assert"
 )
 
 var CameraIP string
 
-func init() {
+// TestMain runs the tests against the camera at CAMERA_IP, or against an
+// emulated camera if the variable is not set.
+func TestMain(m *testing.M) {
 	CameraIP = os.Getenv("CAMERA_IP")
+	if CameraIP != "" {
+		os.Exit(m.Run())
+	}
+
+	srv := zcamtest.NewServer()
+	srv.Camera.AddFile(zcamtest.DefaultFolder, zcamtest.NewClip(
+		"ZCAM0001_0000_202401011200.MOV", time.Now(), 10*time.Second,
+	))
+
+	CameraIP = srv.Listener.Addr().String()
+	code := m.Run()
+	srv.Close()
+	os.Exit(code)
 }
 
 func TestGetCameraInfo(t *testing.T) {
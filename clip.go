@@ -0,0 +1,123 @@
+package zcam
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+)
+
+// proxySuffix is appended by the camera to the base name of the clip for the
+// proxy file, such as ZCAM0001_0000_202405011000_proxy.MOV. It's compared
+// ignoring the case, as the extensions are.
+const proxySuffix = "_proxy"
+
+// Clip groups the files of the camera sharing the same base name, such as
+// ZCAM0001_0000_202405011000.
+type Clip struct {
+	// Main is the video file, nil for the stills captured without
+	// recording.
+	Main *File
+	// Proxy is the low resolution copy of the video, only when
+	// settings.RecProxyFileSetting is enabled.
+	Proxy *File
+	// Stills are the JPEG files of the clip.
+	Stills []*File
+}
+
+// Name returns the base name of the clip, without the extension.
+func (c *Clip) Name() string {
+	for _, f := range append([]*File{c.Main, c.Proxy}, c.Stills...) {
+		if f != nil {
+			return clipName(f.file)
+		}
+	}
+
+	return ""
+}
+
+// Files returns the main file, the proxy and the stills, the ones present.
+func (c *Clip) Files() []*File {
+	var files []*File
+	for _, f := range []*File{c.Main, c.Proxy} {
+		if f != nil {
+			files = append(files, f)
+		}
+	}
+
+	return append(files, c.Stills...)
+}
+
+// clipName returns the base name of the clip of a file, without the
+// extension and the proxy suffix.
+func clipName(filename string) string {
+	name, _ := trimProxy(filename)
+	return name
+}
+
+func isProxy(filename string) bool {
+	_, ok := trimProxy(filename)
+	return ok
+}
+
+// trimProxy returns the file name without the extension and the proxy
+// suffix, and whether it had the suffix.
+func trimProxy(filename string) (string, bool) {
+	name := strings.TrimSuffix(filename, path.Ext(filename))
+	n := len(name) - len(proxySuffix)
+	if n > 0 && strings.EqualFold(name[n:], proxySuffix) {
+		return name[:n], true
+	}
+
+	return name, false
+}
+
+func isStill(filename string) bool {
+	ext := path.Ext(filename)
+	return strings.EqualFold(ext, ".jpg") || strings.EqualFold(ext, ".jpeg")
+}
+
+// groupClips groups the files by folder and clip name, sorted by folder and
+// name.
+func groupClips(files []*File) []*Clip {
+	clips := make(map[string]*Clip)
+	var keys []string
+	for _, f := range files {
+		key := f.folder + "/" + clipName(f.file)
+		c, ok := clips[key]
+		if !ok {
+			c = &Clip{}
+			clips[key] = c
+			keys = append(keys, key)
+		}
+
+		switch {
+		case isStill(f.file):
+			c.Stills = append(c.Stills, f)
+		case isProxy(f.file):
+			c.Proxy = f
+		default:
+			c.Main = f
+		}
+	}
+
+	sort.Strings(keys)
+
+	result := make([]*Clip, len(keys))
+	for i, key := range keys {
+		result[i] = clips[key]
+	}
+
+	return result
+}
+
+// ListClips lists the files in all the folders grouped by clip, pairing every
+// video with its proxy and stills, sorted by folder and name.
+func (c *Camera) ListClips(ctx context.Context) ([]*Clip, error) {
+	files, err := c.ListAllFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return groupClips(files), nil
+}
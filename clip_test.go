@@ -0,0 +1,65 @@
+package zcam
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mcuadros/go-zcam-e2/zcamtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListClips(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	now := time.Now()
+	srv.Camera.AddFile(zcamtest.DefaultFolder, zcamtest.NewClip("ZCAM0002_0000_202405011000.MOV", now, time.Second))
+	srv.Camera.AddFile(zcamtest.DefaultFolder, zcamtest.NewClip("ZCAM0001_0000_202405010900_proxy.MOV", now, time.Second))
+	srv.Camera.AddFile(zcamtest.DefaultFolder, zcamtest.NewClip("ZCAM0001_0000_202405010900.MOV", now, time.Second))
+	srv.Camera.AddFile(zcamtest.DefaultFolder, &zcamtest.File{Name: "ZCAM0001_0000_202405010900.JPG", CreatedAt: now})
+	srv.Camera.AddFile(zcamtest.DefaultFolder, &zcamtest.File{Name: "ZCAM0003_0000_202405011100.JPG", CreatedAt: now})
+	srv.Camera.AddFile(zcamtest.DefaultFolder, zcamtest.NewClip("ZCAM0004_0000_202405011200.mov", now, time.Second))
+	srv.Camera.AddFile(zcamtest.DefaultFolder, zcamtest.NewClip("ZCAM0004_0000_202405011200_PROXY.mov", now, time.Second))
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	clips, err := cli.ListClips(context.Background())
+	require.NoError(t, err)
+	require.Len(t, clips, 4)
+
+	require.Equal(t, "ZCAM0001_0000_202405010900", clips[0].Name())
+	require.Equal(t, "ZCAM0001_0000_202405010900.MOV", clips[0].Main.Filename())
+	require.Equal(t, "ZCAM0001_0000_202405010900_proxy.MOV", clips[0].Proxy.Filename())
+	require.Len(t, clips[0].Stills, 1)
+	require.Len(t, clips[0].Files(), 3)
+
+	require.Equal(t, "ZCAM0002_0000_202405011000.MOV", clips[1].Main.Filename())
+	require.Nil(t, clips[1].Proxy)
+
+	require.Nil(t, clips[2].Main)
+	require.Equal(t, "ZCAM0003_0000_202405011100", clips[2].Name())
+	require.Equal(t, "ZCAM0003_0000_202405011100.JPG", clips[2].Stills[0].Filename())
+
+	require.Equal(t, "ZCAM0004_0000_202405011200.mov", clips[3].Main.Filename())
+	require.Equal(t, "ZCAM0004_0000_202405011200_PROXY.mov", clips[3].Proxy.Filename())
+}
+
+func TestClipName(t *testing.T) {
+	for _, tc := range []struct {
+		filename string
+		name     string
+		proxy    bool
+	}{
+		{"ZCAM0001_0000_202405011000.MOV", "ZCAM0001_0000_202405011000", false},
+		{"ZCAM0001_0000_202405011000_proxy.MOV", "ZCAM0001_0000_202405011000", true},
+		{"ZCAM0001_0000_202405011000_PROXY.MOV", "ZCAM0001_0000_202405011000", true},
+		{"ZCAM0001_0000_202405011000_Proxy.mov", "ZCAM0001_0000_202405011000", true},
+		{"A001C0002_20240501100000_0001.MOV", "A001C0002_20240501100000_0001", false},
+		{"A001C0002_20240501100000_0001_proxy.MOV", "A001C0002_20240501100000_0001", true},
+		{"ZCAM0001_0000_202405011000.JPG", "ZCAM0001_0000_202405011000", false},
+		{"_proxy.MOV", "_proxy", false},
+	} {
+		require.Equal(t, tc.name, clipName(tc.filename), tc.filename)
+		require.Equal(t, tc.proxy, isProxy(tc.filename), tc.filename)
+	}
+}
@@ -0,0 +1,669 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mcuadros/go-zcam-e2"
+	"github.com/mcuadros/go-zcam-e2/settings"
+)
+
+var commands = map[string]command{
+	"info": {
+		help: "show the camera information",
+		run:  infoCommand,
+	},
+	"discover": {
+		usage: "[subnet...]",
+		help:  "find cameras using mDNS and sweeping the given subnets",
+		run:   discoverCommand,
+	},
+	"session": {
+		usage: "start|quit",
+		help:  "start or quit the control session",
+		run:   sessionCommand,
+	},
+	"mode": {
+		usage: "[rec|pb|rec_tl|standby]",
+		help:  "show the working mode, or switch to the given one",
+		run:   modeCommand,
+	},
+	"caps": {
+		help: "list the settings supported by the camera, -json dumps them",
+		run:  capsCommand,
+	},
+	"get": {
+		usage: "<setting>",
+		help:  "show the value and options of a setting",
+		run:   getCommand,
+	},
+	"set": {
+		usage: "<setting> <value>",
+		help:  "change the value of a setting",
+		run:   setCommand,
+	},
+	"profile": {
+		usage: "save|apply <file>",
+		help:  "save the camera settings to a JSON or YAML file, or apply them",
+		run:   profileCommand,
+	},
+	"diff": {
+		usage: "<address>|-profile <file>",
+		help:  "compare the settings with another camera or a saved profile",
+		run:   diffCommand,
+	},
+	"rec": {
+		usage: "start|stop|status|remain|for <duration>",
+		help:  "control the video recording",
+		run:   recCommand,
+	},
+	"still": {
+		help: "capture a still",
+		run:  stillCommand,
+	},
+	"ls": {
+		usage: "[folder]",
+		help:  "list the files in the card",
+		run:   lsCommand,
+	},
+	"download": {
+		usage:    "<path> [destination] [thumbnail|screennail]",
+		help:     "download a file from the card",
+		run:      downloadCommand,
+		transfer: true,
+	},
+	"rm": {
+		usage: "<path>...",
+		help:  "delete files from the card",
+		run:   rmCommand,
+	},
+	"card": {
+		usage: "status|format -yes [fat32|exfat]",
+		help:  "show the card status or format it, erasing every file",
+		run:   cardCommand,
+	},
+	"stream": {
+		usage: "show <stream>|source <stream>|set <stream> <key=value>...",
+		help:  "show or configure the network streams",
+		run:   streamCommand,
+	},
+	"network": {
+		usage: "set router|direct|static [<ip/prefix> <gateway>]",
+		help:  "configure the network mode",
+		run:   networkCommand,
+	},
+	"temp": {
+		help: "show the camera temperature",
+		run:  tempCommand,
+	},
+	"reboot": {
+		help: "reboot the camera",
+		run:  rebootCommand,
+	},
+	"shutdown": {
+		help: "shutdown the camera",
+		run:  shutdownCommand,
+	},
+}
+
+func infoCommand(ctx context.Context, a *app, args []string) error {
+	info, err := a.cli.GetCameraInfo(ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.print(info, func(w io.Writer) {
+		fmt.Fprintf(w, "Model:    %s\n", info.Model)
+		fmt.Fprintf(w, "Number:   %s\n", info.Number)
+		fmt.Fprintf(w, "Serial:   %s\n", info.SN)
+		fmt.Fprintf(w, "Firmware: %s\n", info.Sw)
+		fmt.Fprintf(w, "Hardware: %s\n", info.Hw)
+		fmt.Fprintf(w, "MAC:      %s\n", info.Mac)
+		fmt.Fprintf(w, "IP:       %s\n", info.EthIP)
+	})
+}
+
+func discoverCommand(ctx context.Context, a *app, args []string) error {
+	opts := zcam.DiscoverOptions{MDNS: true}
+	for _, arg := range args {
+		prefix, err := netip.ParsePrefix(arg)
+		if err != nil {
+			return fmt.Errorf("invalid subnet %q: %w", arg, err)
+		}
+
+		opts.Subnets = append(opts.Subnets, prefix)
+	}
+
+	cameras, err := zcam.Discover(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	return a.print(cameras, func(w io.Writer) {
+		for _, c := range cameras {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", c.Addr, c.Model, c.SN)
+		}
+	})
+}
+
+func sessionCommand(ctx context.Context, a *app, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "start":
+		return a.cli.StartSession(ctx)
+	case "quit":
+		return a.cli.QuitSession(ctx)
+	default:
+		return errUsage
+	}
+}
+
+func modeCommand(ctx context.Context, a *app, args []string) error {
+	switch len(args) {
+	case 0:
+	case 1:
+		wctx := ctx
+		if a.wait > 0 {
+			var cancel context.CancelFunc
+			wctx, cancel = context.WithTimeout(ctx, a.wait)
+			defer cancel()
+		}
+
+		if err := a.cli.EnsureMode(wctx, zcam.WorkingMode(args[0])); err != nil {
+			return err
+		}
+	default:
+		return errUsage
+	}
+
+	m, err := a.cli.QueryWorkingMode(ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.print(map[string]zcam.WorkingMode{"mode": m}, func(w io.Writer) {
+		fmt.Fprintf(w, "Mode: %s\n", m)
+	})
+}
+
+func getCommand(ctx context.Context, a *app, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	v, err := a.cli.GetSetting(ctx, settings.Setting(args[0]))
+	if err != nil {
+		return err
+	}
+
+	return a.print(v, func(w io.Writer) {
+		fmt.Fprint(w, v)
+	})
+}
+
+func capsCommand(ctx context.Context, a *app, args []string) error {
+	caps, err := a.cli.Capabilities(ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.print(caps, func(w io.Writer) {
+		for _, s := range caps.Settings {
+			var detail string
+			switch s.Type {
+			case zcam.ChoiceSettingType:
+				detail = strings.Join(s.Options, ", ")
+			case zcam.RangeSettingType:
+				detail = fmt.Sprintf("%d..%d step %d", s.Min, s.Max, s.Step)
+			}
+
+			if s.ReadOnly {
+				detail = strings.TrimSpace("read-only " + detail)
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\n", s.Key, s.Type, detail)
+		}
+	})
+}
+
+func setCommand(ctx context.Context, a *app, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+
+	return a.cli.SetSetting(ctx, settings.Setting(args[0]), args[1])
+}
+
+func profileCommand(ctx context.Context, a *app, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "save":
+		p, err := a.cli.Snapshot(ctx)
+		if err != nil {
+			return err
+		}
+
+		if err := p.Save(args[1]); err != nil {
+			return err
+		}
+
+		return a.print(map[string]any{"file": args[1], "settings": len(p.Settings), "skipped": p.Skipped}, func(w io.Writer) {
+			fmt.Fprintf(w, "%d setting(s) saved to %s, %d skipped\n", len(p.Settings), args[1], len(p.Skipped))
+		})
+	case "apply":
+		p, err := zcam.LoadProfile(args[1])
+		if err != nil {
+			return err
+		}
+
+		r, err := a.cli.ApplyProfile(ctx, p)
+		if err != nil {
+			return err
+		}
+
+		return a.print(r, func(w io.Writer) {
+			fmt.Fprint(w, r)
+		})
+	default:
+		return errUsage
+	}
+}
+
+func diffCommand(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	profile := fs.String("profile", "", "profile file to compare with")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if (*profile == "") == (len(args) == 0) || len(args) > 1 {
+		return errUsage
+	}
+
+	var diff zcam.SettingsDiff
+	if *profile != "" {
+		p, err := zcam.LoadProfile(*profile)
+		if err != nil {
+			return err
+		}
+
+		current, err := a.cli.Snapshot(ctx)
+		if err != nil {
+			return err
+		}
+
+		diff = zcam.DiffProfiles(current, p)
+	} else {
+		diff, err = zcam.DiffSettings(ctx, a.cli, zcam.NewCamera(args[0], a.opts...))
+		if err != nil {
+			return err
+		}
+	}
+
+	return a.print(diff, func(w io.Writer) {
+		if len(diff) == 0 {
+			fmt.Fprintln(w, "No differences")
+			return
+		}
+
+		fmt.Fprint(w, diff)
+	})
+}
+
+func recCommand(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "start":
+		return a.cli.StartVideoRecordAndWait(ctx)
+	case "stop":
+		return a.cli.StopVideoRecordAndWait(ctx)
+	case "status":
+		s, err := a.cli.QueryRecordingStatus(ctx)
+		if err != nil {
+			return err
+		}
+
+		return a.print(map[string]any{"state": s.State, "elapsed_seconds": int(s.Elapsed.Seconds())}, func(w io.Writer) {
+			fmt.Fprintf(w, "%s %s\n", s.State, s.Elapsed)
+		})
+	case "remain":
+		d, err := a.cli.QueryRemainingRecordingTime(ctx)
+		if err != nil {
+			return err
+		}
+
+		return a.print(map[string]any{"remaining_minutes": int(d.Minutes())}, func(w io.Writer) {
+			fmt.Fprintln(w, d)
+		})
+	case "for":
+		if len(args) != 2 {
+			return errUsage
+		}
+
+		d, err := time.ParseDuration(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", args[1], err)
+		}
+
+		f, err := a.cli.VideoRecord(ctx, d)
+		if err != nil {
+			return err
+		}
+
+		return printFile(a, f)
+	default:
+		return errUsage
+	}
+}
+
+func stillCommand(ctx context.Context, a *app, args []string) error {
+	f, err := a.cli.CaptureStill(ctx)
+	if err != nil {
+		return err
+	}
+
+	return printFile(a, f)
+}
+
+func lsCommand(ctx context.Context, a *app, args []string) error {
+	var files []*zcam.File
+	var err error
+	switch len(args) {
+	case 0:
+		files, err = a.cli.ListAllFiles(ctx)
+	case 1:
+		files, err = a.cli.ListFiles(ctx, args[0])
+	default:
+		return errUsage
+	}
+
+	if err != nil {
+		return err
+	}
+
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, filePath(f))
+	}
+
+	return a.print(paths, func(w io.Writer) {
+		for _, p := range paths {
+			fmt.Fprintln(w, p)
+		}
+	})
+}
+
+func downloadCommand(ctx context.Context, a *app, args []string) error {
+	if len(args) < 1 || len(args) > 3 {
+		return errUsage
+	}
+
+	f, err := zcam.NewFile(a.cli, args[0])
+	if err != nil {
+		return err
+	}
+
+	dst := f.Filename()
+	if len(args) > 1 {
+		dst = args[1]
+	}
+
+	format := zcam.Original
+	if len(args) > 2 {
+		format = zcam.Format(args[2])
+	}
+
+	n, err := f.Download(ctx, format, dst)
+	if err != nil {
+		return err
+	}
+
+	return a.print(map[string]any{"path": filePath(f), "file": dst, "bytes": n}, func(w io.Writer) {
+		fmt.Fprintf(w, "%s downloaded to %s, %d bytes\n", filePath(f), dst, n)
+	})
+}
+
+func rmCommand(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	for _, p := range args {
+		f, err := zcam.NewFile(a.cli, p)
+		if err != nil {
+			return err
+		}
+
+		if err := f.Delete(ctx); err != nil {
+			return fmt.Errorf("error deleting %s: %w", p, err)
+		}
+	}
+
+	return nil
+}
+
+func cardCommand(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "status":
+		return cardStatus(ctx, a)
+	case "format":
+		return cardFormat(ctx, a, args[1:])
+	}
+
+	return errUsage
+}
+
+// errNotConfirmed is returned by card format when run without -yes.
+var errNotConfirmed = errors.New("this erases every file in the card, confirm it with -yes")
+
+func cardFormat(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("format", flag.ContinueOnError)
+	yes := fs.Bool("yes", false, "confirm the format")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if !*yes {
+		return errNotConfirmed
+	}
+
+	switch len(args) {
+	case 0:
+		return a.cli.FormatCard(ctx)
+	case 1:
+		return a.cli.FormatCardAs(ctx, args[0])
+	}
+
+	return errUsage
+}
+
+func cardStatus(ctx context.Context, a *app) error {
+	status := struct {
+		Present bool `json:"present"`
+		TotalMB int  `json:"total_mb"`
+		FreeMB  int  `json:"free_mb"`
+	}{}
+
+	var err error
+	if status.Present, err = a.cli.CheckCardPresence(ctx); err != nil {
+		return err
+	}
+
+	if status.Present {
+		if status.TotalMB, err = a.cli.QueryCardTotalSpace(ctx); err != nil {
+			return err
+		}
+
+		if status.FreeMB, err = a.cli.QueryCardFreeSpace(ctx); err != nil {
+			return err
+		}
+	}
+
+	return a.print(status, func(w io.Writer) {
+		if !status.Present {
+			fmt.Fprintln(w, "No card present")
+			return
+		}
+
+		fmt.Fprintf(w, "Total: %d MB\n", status.TotalMB)
+		fmt.Fprintf(w, "Free:  %d MB\n", status.FreeMB)
+	})
+}
+
+func streamCommand(ctx context.Context, a *app, args []string) error {
+	if len(args) < 2 {
+		return errUsage
+	}
+
+	stream := zcam.Stream(args[1])
+	switch args[0] {
+	case "show":
+		cfg, err := a.cli.QueryStreamSetting(ctx, stream)
+		if err != nil {
+			return err
+		}
+
+		return a.print(cfg, func(w io.Writer) {
+			fmt.Fprintf(w, "Stream:   %s\n", cfg.Stream)
+			fmt.Fprintf(w, "Status:   %s\n", cfg.Status)
+			fmt.Fprintf(w, "Encoder:  %s %s\n", cfg.EncoderType, cfg.Bitwidth)
+			fmt.Fprintf(w, "Size:     %dx%d\n", cfg.Width, cfg.Height)
+			fmt.Fprintf(w, "FPS:      %d\n", cfg.FPS)
+			fmt.Fprintf(w, "Bitrate:  %d\n", cfg.Bitrate)
+			fmt.Fprintf(w, "GOP:      %d\n", cfg.GopN)
+		})
+	case "source":
+		return a.cli.SetStreamSource(ctx, stream)
+	case "set":
+		values := make(map[zcam.Setting]string)
+		for _, kv := range args[2:] {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok {
+				return fmt.Errorf("invalid stream setting %q, expected key=value", kv)
+			}
+
+			values[zcam.Setting(k)] = v
+		}
+
+		return a.cli.SetStreamSettings(ctx, stream, values)
+	default:
+		return errUsage
+	}
+}
+
+func networkCommand(ctx context.Context, a *app, args []string) error {
+	if len(args) < 2 || args[0] != "set" {
+		return errUsage
+	}
+
+	var mode zcam.NetworkMode
+	var ip, gateway net.IP
+	var mask net.IPMask
+	switch strings.ToLower(args[1]) {
+	case "router":
+		mode = zcam.NetworkModeRouter
+	case "direct":
+		mode = zcam.NetworkModeDirect
+	case "static":
+		if len(args) != 4 {
+			return errUsage
+		}
+
+		var ipnet *net.IPNet
+		var err error
+		if ip, ipnet, err = net.ParseCIDR(args[2]); err != nil {
+			return fmt.Errorf("invalid address %q: %w", args[2], err)
+		}
+
+		mode, mask, gateway = zcam.NetworkModeStatic, ipnet.Mask, net.ParseIP(args[3])
+		if gateway == nil {
+			return fmt.Errorf("invalid gateway %q", args[3])
+		}
+	default:
+		return errUsage
+	}
+
+	r, err := a.cli.SetNetworkMode(ctx, mode, ip, mask, gateway)
+	if err != nil {
+		return err
+	}
+
+	return a.print(r, func(w io.Writer) {
+		fmt.Fprintf(w, "Mode: %s\n", r.Mode)
+	})
+}
+
+func tempCommand(ctx context.Context, a *app, args []string) error {
+	t, err := a.cli.QueryTemperature(ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.print(map[string]int{"celsius": t}, func(w io.Writer) {
+		fmt.Fprintf(w, "%d°C\n", t)
+	})
+}
+
+func rebootCommand(ctx context.Context, a *app, args []string) error {
+	return a.cli.RebootSystem(ctx)
+}
+
+func shutdownCommand(ctx context.Context, a *app, args []string) error {
+	return a.cli.ShutdownSystem(ctx)
+}
+
+func printFile(a *app, f *zcam.File) error {
+	return a.print(map[string]string{"path": filePath(f)}, func(w io.Writer) {
+		fmt.Fprintln(w, filePath(f))
+	})
+}
+
+func filePath(f *zcam.File) string {
+	return path.Join(zcam.RootFolder, f.Folder(), f.Filename())
+}
+
+// parseArgs parses the flags of a command, returning the remaining
+// arguments. Unlike flag.Parse, the flags are accepted after the arguments
+// too, such as "format fat32 -yes". The errors are reported as errUsage,
+// printing the usage of the command.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+
+	var rest []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, errUsage
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return rest, nil
+		}
+
+		rest = append(rest, args[0])
+		args = args[1:]
+	}
+}
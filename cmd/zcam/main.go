@@ -0,0 +1,172 @@
+// Command zcam controls a Z CAM E2 camera from the command line.
+//
+// Usage:
+//
+//	zcam [flags] <command> [arguments]
+//
+// The camera address is read from the -addr flag, or from the ZCAM_ADDR or
+// CAMERA_IP environment variables. Run "zcam help" for the list of commands.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	"github.com/mcuadros/go-zcam-e2"
+)
+
+var errUsage = errors.New("invalid usage")
+
+type command struct {
+	usage string
+	help  string
+	run   func(ctx context.Context, a *app, args []string) error
+	// transfer is set by the commands transferring files, the timeout only
+	// limits the wait for the response headers, not the whole transfer.
+	transfer bool
+}
+
+// app holds the state shared by all the commands.
+type app struct {
+	cli *zcam.Camera
+	// opts are the options used to create cli, to connect to other cameras.
+	opts []zcam.Option
+	json bool
+	// wait limits the commands waiting for the camera, such as mode.
+	wait   time.Duration
+	stdout io.Writer
+}
+
+// print writes v as JSON if requested, otherwise calls human.
+func (a *app) print(v any, human func(w io.Writer)) error {
+	if !a.json {
+		human(a.stdout)
+		return nil
+	}
+
+	enc := json.NewEncoder(a.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	err := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	if errors.Is(err, errUsage) {
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "zcam: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("zcam", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	addr := fs.String("addr", defaultAddr(), "camera address, defaults to $ZCAM_ADDR or $CAMERA_IP")
+	asJSON := fs.Bool("json", false, "print the output as JSON")
+	timeout := fs.Duration("timeout", 30*time.Second, "timeout of every request, or of the response headers of a download, 0 disables it")
+	wait := fs.Duration("wait", time.Minute, "maximum time waiting for the camera to switch mode, 0 disables it")
+	user := fs.String("user", os.Getenv("ZCAM_USER"), "username, defaults to $ZCAM_USER")
+	password := fs.String("password", os.Getenv("ZCAM_PASSWORD"), "password, defaults to $ZCAM_PASSWORD")
+	retries := fs.Int("retries", 1, "maximum number of attempts of the failed requests")
+	validate := fs.Bool("validate", true, "check the values against the camera options before setting them")
+	fs.Usage = func() { usage(fs) }
+
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if fs.NArg() == 0 || fs.Arg(0) == "help" {
+		fs.Usage()
+		return nil
+	}
+
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "zcam: unknown command %q\n", fs.Arg(0))
+		fs.Usage()
+		return errUsage
+	}
+
+	opts := []zcam.Option{zcam.WithUserAgent("zcam-cli")}
+	if cmd.transfer {
+		opts = append(opts, zcam.WithTransport(headerTimeoutTransport(*timeout)))
+	} else {
+		opts = append(opts, zcam.WithTimeout(*timeout))
+	}
+	if *user != "" {
+		opts = append(opts, zcam.WithCredentials(*user, *password))
+	}
+
+	if *validate {
+		opts = append(opts, zcam.WithValidation())
+	}
+
+	if *retries > 1 {
+		p := zcam.DefaultRetryPolicy()
+		p.MaxAttempts = *retries
+		opts = append(opts, zcam.WithRetryPolicy(p))
+	}
+
+	if *addr == "" && fs.Arg(0) != "discover" {
+		return fmt.Errorf("missing camera address, use -addr or $ZCAM_ADDR")
+	}
+
+	a := &app{cli: zcam.NewCamera(*addr, opts...), opts: opts, json: *asJSON, wait: *wait, stdout: stdout}
+	err := cmd.run(ctx, a, fs.Args()[1:])
+	if errors.Is(err, errUsage) {
+		fmt.Fprintf(stderr, "usage: zcam %s %s\n", fs.Arg(0), cmd.usage)
+	}
+
+	return err
+}
+
+// headerTimeoutTransport returns a transport limiting the wait for the
+// response headers, allowing the body to take as long as needed.
+func headerTimeoutTransport(timeout time.Duration) http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.ResponseHeaderTimeout = timeout
+	return t
+}
+
+func defaultAddr() string {
+	if addr := os.Getenv("ZCAM_ADDR"); addr != "" {
+		return addr
+	}
+
+	return os.Getenv("CAMERA_IP")
+}
+
+func usage(fs *flag.FlagSet) {
+	w := fs.Output()
+	fmt.Fprintf(w, "Usage: zcam [flags] <command> [arguments]\n\nCommands:\n")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].help)
+		fmt.Fprintf(w, "  %-10s   zcam %s %s\n", "", name, commands[name].usage)
+	}
+
+	fmt.Fprintf(w, "\nFlags:\n")
+	fs.PrintDefaults()
+}
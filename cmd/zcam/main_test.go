@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+	"github.com/mcuadros/go-zcam-e2/zcamtest"
+	"github.com/stretchr/testify/require"
+)
+
+func runCommand(t *testing.T, srv *zcamtest.Server, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	args = append([]string{"-addr", srv.Listener.Addr().String()}, args...)
+	err := run(context.Background(), args, &stdout, &stderr)
+	return stdout.String(), err
+}
+
+func TestInfo(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	out, err := runCommand(t, srv, "info")
+	require.NoError(t, err)
+	require.Contains(t, out, "Model:    E2")
+
+	out, err = runCommand(t, srv, "-json", "info")
+	require.NoError(t, err)
+
+	var info map[string]string
+	require.NoError(t, json.Unmarshal([]byte(out), &info))
+	require.Equal(t, "329A0010009", info["sn"])
+}
+
+func TestGetSet(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	_, err := runCommand(t, srv, "set", "iso", "800")
+	require.NoError(t, err)
+	require.Equal(t, "800", srv.Camera.Value(settings.ISOSetting))
+
+	out, err := runCommand(t, srv, "get", "iso")
+	require.NoError(t, err)
+	require.Contains(t, out, "Value: 800")
+
+	_, err = runCommand(t, srv, "set", "iso")
+	require.ErrorIs(t, err, errUsage)
+
+	_, err = runCommand(t, srv, "set", "flicker", "70Hz")
+	require.ErrorContains(t, err, "valid choices: 50Hz, 60Hz")
+}
+
+func TestMode(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	out, err := runCommand(t, srv, "mode")
+	require.NoError(t, err)
+	require.Equal(t, "Mode: rec\n", out)
+
+	out, err = runCommand(t, srv, "mode", "pb")
+	require.NoError(t, err)
+	require.Equal(t, "Mode: pb\n", out)
+	require.Equal(t, zcamtest.ModePlayback, srv.Camera.Mode())
+
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/ctrl/mode", Latency: time.Second})
+	_, err = runCommand(t, srv, "-wait", "100ms", "mode", "rec")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestCaps(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	out, err := runCommand(t, srv, "caps")
+	require.NoError(t, err)
+	require.Contains(t, out, "mwb\trange\t2300..10000 step 100\n")
+	require.Contains(t, out, "flicker\tchoice\t50Hz, 60Hz\n")
+	require.NotContains(t, out, "vignette")
+}
+
+func TestProfile(t *testing.T) {
+	src := zcamtest.NewServer()
+	defer src.Close()
+
+	src.Camera.SetValue(settings.ISOSetting, "800")
+	filename := filepath.Join(t.TempDir(), "profile.yaml")
+	_, err := runCommand(t, src, "profile", "save", filename)
+	require.NoError(t, err)
+
+	dst := zcamtest.NewServer()
+	defer dst.Close()
+
+	out, err := runCommand(t, dst, "profile", "apply", filename)
+	require.NoError(t, err)
+	require.Contains(t, out, `iso: "Auto" -> "800"`)
+	require.Equal(t, "800", dst.Camera.Value(settings.ISOSetting))
+}
+
+func TestDiff(t *testing.T) {
+	a := zcamtest.NewServer()
+	defer a.Close()
+
+	b := zcamtest.NewServer()
+	defer b.Close()
+
+	out, err := runCommand(t, a, "diff", b.Listener.Addr().String())
+	require.NoError(t, err)
+	require.Equal(t, "No differences\n", out)
+
+	b.Camera.SetValue(settings.ISOSetting, "800")
+	filename := filepath.Join(t.TempDir(), "profile.json")
+	_, err = runCommand(t, b, "profile", "save", filename)
+	require.NoError(t, err)
+
+	out, err = runCommand(t, a, "diff", "-profile", filename)
+	require.NoError(t, err)
+	require.Equal(t, "EXPOSURE\n  iso: \"Auto\" != \"800\"\n", out)
+}
+
+func TestRecordListDownloadRemove(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	out, err := runCommand(t, srv, "rec", "status")
+	require.NoError(t, err)
+	require.Equal(t, "idle 0s\n", out)
+
+	out, err = runCommand(t, srv, "-json", "rec", "for", "10ms")
+	require.NoError(t, err)
+
+	var file map[string]string
+	require.NoError(t, json.Unmarshal([]byte(out), &file))
+
+	out, err = runCommand(t, srv, "ls")
+	require.NoError(t, err)
+	require.Equal(t, file["path"]+"\n", out)
+
+	dst := filepath.Join(t.TempDir(), "clip.MOV")
+	_, err = runCommand(t, srv, "download", file["path"], dst)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Len(t, data, 1024)
+
+	_, err = runCommand(t, srv, "rm", file["path"])
+	require.NoError(t, err)
+	require.Len(t, srv.Camera.Files(zcamtest.DefaultFolder), 0)
+}
+
+func TestDownloadSlowTransfer(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	clip := zcamtest.NewClip("clip.MOV", time.Now(), 10*time.Second)
+	srv.Camera.AddFile(zcamtest.DefaultFolder, clip)
+
+	// the transfer takes about 200ms, longer than the timeout
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/DCIM/", Throttle: 20 * time.Millisecond})
+
+	dst := filepath.Join(t.TempDir(), "clip.MOV")
+	_, err := runCommand(t, srv, "-timeout", "100ms", "download", "/DCIM/"+zcamtest.DefaultFolder+"/clip.MOV", dst)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, clip.Data, data)
+}
+
+func TestCard(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	srv.Camera.AddFile(zcamtest.DefaultFolder, zcamtest.NewClip("clip.MOV", time.Now(), time.Second))
+
+	_, err := runCommand(t, srv, "card", "format", "fat32")
+	require.ErrorIs(t, err, errNotConfirmed)
+	require.Len(t, srv.Camera.Files(zcamtest.DefaultFolder), 1)
+
+	_, err = runCommand(t, srv, "card", "format", "fat32", "-yes")
+	require.NoError(t, err)
+	require.Len(t, srv.Camera.Files(zcamtest.DefaultFolder), 0)
+
+	srv.Camera.AddFile(zcamtest.DefaultFolder, zcamtest.NewClip("clip.MOV", time.Now(), time.Second))
+	_, err = runCommand(t, srv, "card", "format", "-yes", "exfat")
+	require.NoError(t, err)
+	require.Len(t, srv.Camera.Files(zcamtest.DefaultFolder), 0)
+
+	_, err = runCommand(t, srv, "card", "format", "fat32", "-force")
+	require.ErrorIs(t, err, errUsage)
+
+	out, err := runCommand(t, srv, "-json", "card", "status")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"present": true, "total_mb": 122070, "free_mb": 122070}`, out)
+}
+
+func TestStreamAndNetwork(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	_, err := runCommand(t, srv, "stream", "set", "stream1", "width=1280", "height=720")
+	require.NoError(t, err)
+
+	out, err := runCommand(t, srv, "stream", "show", "stream1")
+	require.NoError(t, err)
+	require.Contains(t, out, "Size:     1280x720")
+
+	out, err = runCommand(t, srv, "network", "set", "static", "192.168.1.10/24", "192.168.1.1")
+	require.NoError(t, err)
+	require.Contains(t, out, "Mode: Static")
+}
+
+func TestUnknownCommand(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	_, err := runCommand(t, srv, "foo")
+	require.ErrorIs(t, err, errUsage)
+}
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"net/url"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/mcuadros/go-zcam-e2/settings"
@@ -34,6 +36,19 @@ const (
 	StringSettingType SettingType = 3
 )
 
+func (t SettingType) String() string {
+	switch t {
+	case ChoiceSettingType:
+		return "choice"
+	case RangeSettingType:
+		return "range"
+	case StringSettingType:
+		return "string"
+	default:
+		return fmt.Sprintf("SettingType(%d)", int(t))
+	}
+}
+
 type SettingValue struct {
 	Code     int         `json:"code"`
 	Desc     string      `json:"desc"`
@@ -47,10 +62,19 @@ type SettingValue struct {
 	Step     int         `json:"step,omitempty"` // Only for range type
 }
 
+// Kind returns the kind of the value, reflect.Invalid if there is no value.
 func (v *SettingValue) Kind() reflect.Kind {
+	if v.Value == nil {
+		return reflect.Invalid
+	}
+
 	return reflect.TypeOf(v.Value).Kind()
 }
 
+// MustValueString returns the value as a string, it panics if the value is
+// not a string.
+//
+// Deprecated: use StringValue, returning an error instead.
 func (v *SettingValue) MustValueString() string {
 	i, ok := v.Value.(string)
 	if !ok {
@@ -60,6 +84,10 @@ func (v *SettingValue) MustValueString() string {
 	return i
 }
 
+// MustValueInt returns the value as an int, it panics if the value is not a
+// number.
+//
+// Deprecated: use Int, returning an error instead.
 func (v *SettingValue) MustValueInt() int {
 	i, ok := v.Value.(float64)
 	if !ok {
@@ -74,11 +102,8 @@ func (c *SettingValue) String() string {
 	fmt.Fprintf(buf, "Key: %s\n", c.Key)
 	fmt.Fprintf(buf, "Read-Only: %t\n", c.ReadOnly)
 
-	switch c.Kind() {
-	case reflect.Float64:
-		fmt.Fprintf(buf, "Value: %d\n", c.MustValueInt())
-	case reflect.String:
-		fmt.Fprintf(buf, "Value: %s\n", c.MustValueString())
+	if value, err := c.StringValue(); err == nil {
+		fmt.Fprintf(buf, "Value: %s\n", value)
 	}
 
 	switch c.Type {
@@ -102,12 +127,7 @@ func (c *SettingValue) String() string {
 // CaptureStill capture a single fream from the stream, it returns the captured
 // file.
 func (c *Camera) CaptureStill(ctx context.Context) (*File, error) {
-	body, err := c.get(ctx, "/ctrl/still?action=single")
-	if err != nil {
-		return nil, err
-	}
-
-	if err := decodeBasicRequest(body); err != nil {
+	if err := c.sendControlRequest(ctx, "/ctrl/still?action=single"); err != nil {
 		return nil, err
 	}
 
@@ -119,44 +139,30 @@ func (c *Camera) CaptureStill(ctx context.Context) (*File, error) {
 	return NewFileFromValueSetting(c, v)
 }
 
-// StartVideoRecord starts video recording or video timelapse recording
+// StartVideoRecord starts video recording or video timelapse recording, if
+// rejected because there is no card the error matches ErrNoCard.
 func (c *Camera) StartVideoRecord(ctx context.Context) error {
-	body, err := c.get(ctx, "/ctrl/rec?action=start")
-	if err != nil {
-		return err
+	err := c.sendControlRequest(ctx, "/ctrl/rec?action=start")
+	if isRejected(err) {
+		return c.cardError(ctx, err)
 	}
 
-	return decodeBasicRequest(body)
+	return err
 }
 
 // StopVideoRecord stops video recording or video timelapse recording
 func (c *Camera) StopVideoRecord(ctx context.Context) error {
-	body, err := c.get(ctx, "/ctrl/rec?action=stop")
-	if err != nil {
-		return err
-	}
-
-	return decodeBasicRequest(body)
+	return c.sendControlRequest(ctx, "/ctrl/rec?action=stop")
 }
 
 // VideoRecord records a video of the give duration, returns a File from the
-// setting value of settings.LastFileNameSetting.
+// setting value of settings.LastFileNameSetting, being the last segment when
+// the recording is split, see Record to retrieve all of them. The recording
+// status is checked while recording, if the camera stops by itself, such as
+// when the card is full, an error matching ErrRecordingStopped is returned.
 func (c *Camera) VideoRecord(ctx context.Context, d time.Duration) (*File, error) {
-	if err := c.StartVideoRecord(ctx); err != nil {
-		return nil, fmt.Errorf("error starting video, at cacelled context: %w", err)
-	}
-
-	select {
-	case <-ctx.Done():
-		if err := c.StopVideoRecord(context.Background()); err != nil {
-			return nil, fmt.Errorf("error stopping video, at cacelled context: %w", err)
-		}
-
-		return nil, fmt.Errorf("context cancelled")
-	case <-time.After(d):
-		if err := c.StopVideoRecord(ctx); err != nil {
-			return nil, fmt.Errorf("error stopping video: %w", err)
-		}
+	if err := c.recordFor(ctx, d); err != nil {
+		return nil, err
 	}
 
 	v, err := c.GetSetting(ctx, settings.LastFileNameSetting)
@@ -169,16 +175,14 @@ func (c *Camera) VideoRecord(ctx context.Context, d time.Duration) (*File, error
 
 // QueryRemainingRecordingTime queries the remaining recording time.
 func (c *Camera) QueryRemainingRecordingTime(ctx context.Context) (time.Duration, error) {
-	body, err := c.get(ctx, "/ctrl/rec?action=remain")
+	endpoint := "/ctrl/rec?action=remain"
+	body, err := c.get(ctx, endpoint)
 	if err != nil {
 		return -1, err
 	}
 
-	var r struct {
-		Code int    `json:"code"`
-		Msg  string `json:"msg"`
-	}
-	if err := decodeJSON(body, &r); err != nil {
+	r, err := decodeBasicResponse(endpoint, body)
+	if err != nil {
 		return -1, err
 	}
 
@@ -192,24 +196,57 @@ func (c *Camera) QueryRemainingRecordingTime(ctx context.Context) (time.Duration
 
 // QueryTemperature queries the camera temperature in celsius.
 func (c *Camera) QueryTemperature(ctx context.Context) (int, error) {
-	body, err := c.get(ctx, "/ctrl/temperature")
+	endpoint := "/ctrl/temperature"
+	body, err := c.get(ctx, endpoint)
 	if err != nil {
 		return -1, err
 	}
 
-	var r struct {
-		Code int    `json:"code"`
-		Msg  string `json:"msg"`
-	}
-	if err := decodeJSON(body, &r); err != nil {
+	r, err := decodeBasicResponse(endpoint, body)
+	if err != nil {
 		return -1, err
 	}
 
 	return strconv.Atoi(r.Msg)
 }
 
-// GetSetting retrieves a camera setting based on its key
+// GetSetting retrieves a camera setting based on its key, if Cache is set the
+// value may be served from the cache.
+//
+// The camera rejects the settings it doesn't support with the same code as
+// any other failure, so a rejected setting is requested again after checking
+// that the camera answers other commands. If it's rejected twice, an error
+// matching ErrNotSupported is returned.
 func (c *Camera) GetSetting(ctx context.Context, key settings.Setting) (*SettingValue, error) {
+	if c.Cache != nil {
+		if v, ok := c.values.get(key); ok {
+			return v, nil
+		}
+	}
+
+	gen := c.values.generation()
+	setting, err := c.fetchSetting(ctx, key)
+	if isRejected(err) {
+		if _, qerr := c.QueryWorkingMode(ctx); qerr == nil {
+			setting, err = c.fetchSetting(ctx, key)
+			if isRejected(err) {
+				err = fmt.Errorf("%w: %s: %w", ErrNotSupported, key, err)
+			}
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Cache != nil {
+		c.values.set(key, setting, c.Cache.ttl(key, setting), gen)
+	}
+
+	return setting, nil
+}
+
+func (c *Camera) fetchSetting(ctx context.Context, key settings.Setting) (*SettingValue, error) {
 	endpoint := fmt.Sprintf("/ctrl/get?k=%s", key)
 	body, err := c.get(ctx, endpoint)
 	if err != nil {
@@ -224,41 +261,86 @@ func (c *Camera) GetSetting(ctx context.Context, key settings.Setting) (*Setting
 	return &setting, nil
 }
 
-// SetSetting changes a camera setting for a given key to a specified value
+// SetSetting changes a camera setting for a given key to a specified value,
+// if Validate is enabled the value is checked before being sent.
 func (c *Camera) SetSetting(ctx context.Context, setting settings.Setting, value any) error {
-	endpoint := fmt.Sprintf("/ctrl/set?%s=%s", setting, convertToString(value))
-	body, err := c.get(ctx, endpoint)
+	if c.Validate {
+		if err := c.ValidateSetting(ctx, setting, value); err != nil {
+			return err
+		}
+	}
+
+	str, err := convertToString(value)
 	if err != nil {
-		return err
+		return fmt.Errorf("invalid value for %s setting: %w", setting, err)
 	}
 
-	return decodeBasicRequest(body)
+	// the cached values and definitions are dropped even on failure, since
+	// the camera may have applied the value anyway.
+	defer c.values.invalidate(setting)
+	defer c.schemas.invalidate(setting)
+
+	endpoint := fmt.Sprintf("/ctrl/set?%s=%s", setting, escapeValue(str))
+	return c.sendControlRequest(ctx, endpoint)
 }
 
-// SetSettings changes several camaras settings in a row.
-func (c *Camera) SetSettings(ctx context.Context, settings map[settings.Setting]any) error {
-	for setting, value := range settings {
-		if err := c.SetSetting(ctx, setting, value); err != nil {
-			return fmt.Errorf("error setting %s: %w", setting, err)
+// SetSettings changes several camaras settings in a row. The settings are
+// applied in dependency order, such as the format before the frame rate, and
+// every value is read back to verify it. If any of them fails, the settings
+// already changed are restored to their previous values.
+func (c *Camera) SetSettings(ctx context.Context, values map[settings.Setting]any) error {
+	keys := make([]settings.Setting, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+
+	sortSettings(keys)
+
+	previous := make(map[settings.Setting]string)
+	for _, key := range keys {
+		if err := c.savePrevious(ctx, previous, key); err != nil {
+			return c.rollback(ctx, previous, fmt.Errorf("error setting %s: %w", key, err))
+		}
+
+		if err := c.setAndVerify(ctx, key, values[key]); err != nil {
+			return c.rollback(ctx, previous, fmt.Errorf("error setting %s: %w", key, err))
 		}
 	}
 
 	return nil
 }
 
-func convertToString(input any) string {
+// convertToString formats the values accepted by SetSetting, the booleans
+// are sent as "On" and "Off".
+func convertToString(input any) (string, error) {
 	switch v := input.(type) {
 	case int:
-		return strconv.Itoa(v)
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
 	case float64:
-		return strconv.FormatFloat(v, 'f', -1, 64)
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
 	case string:
-		return v
+		return v, nil
+	case bool:
+		if v {
+			return "On", nil
+		}
+
+		return "Off", nil
 	default:
-		return "Unsupported type"
+		return "", fmt.Errorf("%w: unsupported %T", ErrUnexpectedValueType, input)
 	}
 }
 
+// escapeValue escapes a value for the query string, the spaces are encoded
+// as %20 since values such as "Flexible Zone" are expected verbatim.
+func escapeValue(v string) string {
+	return strings.ReplaceAll(url.QueryEscape(v), "+", "%20")
+}
+
 // TriggerAutoFocus initiates autofocus
 func (c *Camera) TriggerAutoFocus(ctx context.Context) error {
 	return c.sendControlRequest(ctx, "/ctrl/af")
@@ -284,24 +366,36 @@ func (c *Camera) QueryAutoFocusROI(ctx context.Context) error {
 // SetManualFocusDrive adjusts the manual focus in specified increments
 func (c *Camera) SetManualFocusDrive(ctx context.Context, drive int) error {
 	endpoint := fmt.Sprintf("/ctrl/set?mf_drive=%d", drive)
-	return c.sendControlRequest(ctx, endpoint)
+	return c.sendLensRequest(ctx, endpoint, settings.MFDriveSetting, settings.LensFocusPosSetting)
 }
 
 // SetLensFocusPosition sets the focus plane to a specific position
 func (c *Camera) SetLensFocusPosition(ctx context.Context, position int) error {
 	endpoint := fmt.Sprintf("/ctrl/set?lens_focus_pos=%d", position)
-	return c.sendControlRequest(ctx, endpoint)
+	return c.sendLensRequest(ctx, endpoint, settings.LensFocusPosSetting)
 }
 
 // ZoomControl performs zoom actions such as in, out, or stop
 func (c *Camera) ZoomControl(ctx context.Context, action string) error {
 	endpoint := fmt.Sprintf("/ctrl/set?lens_zoom=%s", action)
-	return c.sendControlRequest(ctx, endpoint)
+	return c.sendLensRequest(ctx, endpoint, settings.LensZoomSetting, settings.LensZoomPosSetting)
 }
 
 // SetZoomPosition sets the zoom to a specific position within a valid range
 func (c *Camera) SetZoomPosition(ctx context.Context, position int) error {
 	endpoint := fmt.Sprintf("/ctrl/set?lens_zoom_pos=%d", position)
+	return c.sendLensRequest(ctx, endpoint, settings.LensZoomPosSetting)
+}
+
+// sendLensRequest sends a request moving the lens, dropping the cached
+// values of the settings it changes, as SetSetting does.
+func (c *Camera) sendLensRequest(ctx context.Context, endpoint string, changed ...settings.Setting) error {
+	defer func() {
+		for _, s := range changed {
+			c.values.invalidate(s)
+		}
+	}()
+
 	return c.sendControlRequest(ctx, endpoint)
 }
 
@@ -312,5 +406,5 @@ func (c *Camera) sendControlRequest(ctx context.Context, endpoint string) error
 		return err
 	}
 
-	return decodeBasicRequest(body)
+	return decodeBasicRequest(endpoint, body)
 }
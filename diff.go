@@ -0,0 +1,136 @@
+package zcam
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+)
+
+// SettingDifference is a setting with different values on two cameras or
+// profiles, or present only in one of them.
+type SettingDifference struct {
+	Key      settings.Setting  `json:"key"`
+	Category settings.Category `json:"category"`
+	A        string            `json:"a"`
+	B        string            `json:"b"`
+	// MissingA and MissingB are set when the setting is not present, being
+	// read-only or not supported.
+	MissingA bool `json:"missing_a,omitempty"`
+	MissingB bool `json:"missing_b,omitempty"`
+}
+
+// SettingsDiff is the list of differences returned by DiffSettings, sorted
+// by category and setting, in the order they are declared.
+type SettingsDiff []SettingDifference
+
+// ByCategory groups the differences by category.
+func (d SettingsDiff) ByCategory() map[settings.Category][]SettingDifference {
+	m := make(map[settings.Category][]SettingDifference)
+	for _, diff := range d {
+		m[diff.Category] = append(m[diff.Category], diff)
+	}
+
+	return m
+}
+
+// String returns a human readable report, grouped by category.
+func (d SettingsDiff) String() string {
+	var b strings.Builder
+	var last settings.Category
+	for i, diff := range d {
+		if i == 0 || diff.Category != last {
+			last = diff.Category
+			name := string(last)
+			if name == "" {
+				name = "other"
+			}
+
+			fmt.Fprintf(&b, "%s\n", strings.ToUpper(name))
+		}
+
+		fmt.Fprintf(&b, "  %s: %s != %s\n", diff.Key,
+			formatDiffValue(diff.A, diff.MissingA), formatDiffValue(diff.B, diff.MissingB),
+		)
+	}
+
+	return b.String()
+}
+
+func formatDiffValue(v string, missing bool) string {
+	if missing {
+		return "(missing)"
+	}
+
+	return fmt.Sprintf("%q", v)
+}
+
+// DiffSettings compares every known setting of two cameras.
+func DiffSettings(ctx context.Context, a, b *Camera) (SettingsDiff, error) {
+	pa, err := a.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pb, err := b.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return DiffProfiles(pa, pb), nil
+}
+
+// DiffProfiles compares the settings of two profiles, such as a Snapshot of
+// a camera and a saved profile.
+func DiffProfiles(a, b *Profile) SettingsDiff {
+	keys := make(map[settings.Setting]bool)
+	for k := range a.Settings {
+		keys[k] = true
+	}
+
+	for k := range b.Settings {
+		keys[k] = true
+	}
+
+	var d SettingsDiff
+	for k := range keys {
+		va, inA := a.Settings[k]
+		vb, inB := b.Settings[k]
+		if inA && inB && va == vb {
+			continue
+		}
+
+		d = append(d, SettingDifference{
+			Key: k, Category: k.Category(),
+			A: va, B: vb,
+			MissingA: !inA, MissingB: !inB,
+		})
+	}
+
+	rank := make(map[settings.Setting]int, len(settings.All))
+	for i, k := range settings.All {
+		rank[k] = i
+	}
+
+	sort.Slice(d, func(i, j int) bool {
+		ri, ok := rank[d[i].Key]
+		if !ok {
+			ri = len(rank)
+		}
+
+		rj, ok := rank[d[j].Key]
+		if !ok {
+			rj = len(rank)
+		}
+
+		if ri != rj {
+			return ri < rj
+		}
+
+		return d[i].Key < d[j].Key
+	})
+
+	return d
+}
@@ -0,0 +1,57 @@
+package zcam
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+	"github.com/mcuadros/go-zcam-e2/zcamtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSettings(t *testing.T) {
+	a := zcamtest.NewServer()
+	defer a.Close()
+
+	b := zcamtest.NewServer()
+	defer b.Close()
+
+	a.Camera.SetValue(settings.ISOSetting, "800")
+	a.Camera.SetValue(settings.AudioInputGainSetting, 40)
+	b.Camera.SetValue(settings.ResolutionSetting, "C4K")
+	b.Camera.RemoveSetting(settings.TCDropFrameSetting)
+
+	d, err := DiffSettings(context.Background(),
+		NewCamera(a.Listener.Addr().String()),
+		NewCamera(b.Listener.Addr().String()),
+	)
+
+	require.NoError(t, err)
+	require.Equal(t, SettingsDiff{
+		{Key: settings.ResolutionSetting, Category: settings.VideoCategory, A: "4K", B: "C4K"},
+		{Key: settings.ISOSetting, Category: settings.ExposureCategory, A: "800", B: "Auto"},
+		{Key: settings.AudioInputGainSetting, Category: settings.AudioCategory, A: "40", B: "30"},
+		{Key: settings.TCDropFrameSetting, Category: settings.TimecodeCategory, A: "DF", MissingB: true},
+	}, d)
+
+	require.Len(t, d.ByCategory()[settings.VideoCategory], 1)
+	require.Equal(t, "VIDEO\n"+
+		"  resolution: \"4K\" != \"C4K\"\n"+
+		"EXPOSURE\n"+
+		"  iso: \"800\" != \"Auto\"\n"+
+		"AUDIO\n"+
+		"  audio_input_gain: \"40\" != \"30\"\n"+
+		"TIMECODE\n"+
+		"  tc_drop_frame: \"DF\" != (missing)\n", d.String())
+}
+
+func TestDiffProfiles(t *testing.T) {
+	a := &Profile{Settings: map[settings.Setting]string{"iso": "800", "custom": "1"}}
+	b := &Profile{Settings: map[settings.Setting]string{"iso": "800"}}
+
+	require.Equal(t, SettingsDiff{
+		{Key: "custom", A: "1", MissingB: true},
+	}, DiffProfiles(a, b))
+
+	require.Empty(t, DiffProfiles(a, a))
+}
@@ -0,0 +1,260 @@
+package zcam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mcuadros/go-zcam-e2/internal/mdns"
+)
+
+// maxSweepBits limits the size of the swept subnets to 65536 addresses.
+const maxSweepBits = 16
+
+// DiscoverOptions configures how Discover looks for cameras.
+type DiscoverOptions struct {
+	// MDNS enables the discovery using mDNS/DNS-SD.
+	MDNS bool
+	// MDNSService is the DNS-SD service type queried, by default
+	// "_http._tcp.local.".
+	MDNSService string
+	// MDNSAddr is the address where the query is sent, by default the mDNS
+	// multicast group.
+	MDNSAddr string
+	// MDNSWait is how long the answers are collected, by default 1s.
+	MDNSWait time.Duration
+
+	// Subnets are swept probing /info in every address.
+	Subnets []netip.Prefix
+	// Port is the HTTP port probed in the subnets, by default 80.
+	Port int
+
+	// ProbeTimeout limits every /info request, by default 500ms.
+	ProbeTimeout time.Duration
+	// Concurrency is the maximum number of concurrent probes, by default 32.
+	Concurrency int
+	// CameraOptions are used to create the Camera of every probe.
+	CameraOptions []Option
+}
+
+func (o *DiscoverOptions) setDefaults() {
+	if o.MDNSService == "" {
+		o.MDNSService = "_http._tcp.local."
+	}
+
+	if o.MDNSAddr == "" {
+		o.MDNSAddr = mdns.DefaultAddr
+	}
+
+	if o.MDNSWait == 0 {
+		o.MDNSWait = time.Second
+	}
+
+	if o.Port == 0 {
+		o.Port = 80
+	}
+
+	if o.ProbeTimeout == 0 {
+		o.ProbeTimeout = 500 * time.Millisecond
+	}
+
+	if o.Concurrency <= 0 {
+		o.Concurrency = 32
+	}
+}
+
+// DiscoveredCamera is a camera found by Discover.
+type DiscoveredCamera struct {
+	// Addr is the host and port of the camera, to be used with NewCamera.
+	Addr string
+	CameraInfo
+}
+
+// Discover looks for cameras on the local network, using mDNS/DNS-SD and
+// sweeping the given subnets. Every candidate address is probed requesting
+// /info, the ones responding with a camera model are returned, sorted by
+// address.
+func Discover(ctx context.Context, opts DiscoverOptions) ([]DiscoveredCamera, error) {
+	opts.setDefaults()
+
+	var addrs []string
+	if opts.MDNS {
+		found, err := lookupMDNS(ctx, &opts)
+		if err != nil {
+			return nil, fmt.Errorf("error querying mDNS: %w", err)
+		}
+
+		addrs = append(addrs, found...)
+	}
+
+	for _, prefix := range opts.Subnets {
+		hosts, err := subnetHosts(prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ip := range hosts {
+			addrs = append(addrs, net.JoinHostPort(ip.String(), strconv.Itoa(opts.Port)))
+		}
+	}
+
+	return probeCameras(ctx, unique(addrs), &opts), nil
+}
+
+// subnetHosts returns the addresses of the given prefix, excluding the network
+// and broadcast addresses of the IPv4 subnets.
+func subnetHosts(prefix netip.Prefix) ([]netip.Addr, error) {
+	prefix = prefix.Masked()
+	bits := prefix.Addr().BitLen() - prefix.Bits()
+	if !prefix.IsValid() || bits > maxSweepBits {
+		return nil, fmt.Errorf("invalid subnet %s, the maximum size is /%d", prefix, prefix.Addr().BitLen()-maxSweepBits)
+	}
+
+	var hosts []netip.Addr
+	for ip := prefix.Addr(); prefix.Contains(ip); ip = ip.Next() {
+		hosts = append(hosts, ip)
+	}
+
+	if prefix.Addr().Is4() && bits >= 2 {
+		hosts = hosts[1 : len(hosts)-1]
+	}
+
+	return hosts, nil
+}
+
+// lookupMDNS sends a DNS-SD query for the service and returns the addresses
+// of the announced instances.
+func lookupMDNS(ctx context.Context, opts *DiscoverOptions) ([]string, error) {
+	dst, err := net.ResolveUDPAddr("udp", opts.MDNSAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	deadline := time.Now().Add(opts.MDNSWait)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.WriteToUDP(mdns.Query(opts.MDNSService, mdns.TypePTR), dst); err != nil {
+		return nil, err
+	}
+
+	type service struct {
+		host string
+		port uint16
+	}
+
+	var services []service
+	ips := make(map[string][]net.IP)
+
+	buf := make([]byte, 9000)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				break
+			}
+
+			return nil, err
+		}
+
+		m, err := mdns.Parse(buf[:n])
+		if err != nil || !m.Response {
+			continue
+		}
+
+		for _, r := range m.Records {
+			switch r.Type {
+			case mdns.TypeSRV:
+				services = append(services, service{host: strings.ToLower(r.Target), port: r.Port})
+			case mdns.TypeA, mdns.TypeAAAA:
+				host := strings.ToLower(r.Name)
+				ips[host] = append(ips[host], r.IP)
+			}
+		}
+	}
+
+	var addrs []string
+	for _, s := range services {
+		for _, ip := range ips[s.host] {
+			addrs = append(addrs, net.JoinHostPort(ip.String(), strconv.Itoa(int(s.port))))
+		}
+	}
+
+	return addrs, nil
+}
+
+func probeCameras(ctx context.Context, addrs []string, opts *DiscoverOptions) []DiscoveredCamera {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		cameras []DiscoveredCamera
+	)
+
+	cameraOpts := append([]Option{WithTimeout(opts.ProbeTimeout)}, opts.CameraOptions...)
+	sem := make(chan struct{}, opts.Concurrency)
+	for _, addr := range addrs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(addr string) {
+			defer func() { <-sem; wg.Done() }()
+
+			info, err := NewCamera(addr, cameraOpts...).GetCameraInfo(ctx)
+			if err != nil || info.Model == "" {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			cameras = append(cameras, DiscoveredCamera{Addr: addr, CameraInfo: *info})
+		}(addr)
+	}
+
+	wg.Wait()
+
+	sort.Slice(cameras, func(i, j int) bool {
+		return cameras[i].Addr < cameras[j].Addr
+	})
+
+	return cameras
+}
+
+func unique(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var out []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,63 @@
+package zcam
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/mcuadros/go-zcam-e2/zcamtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoverMDNS(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	responder, err := zcamtest.NewMDNSResponder("_http._tcp.local.", "E2", srv.Listener.Addr().(*net.TCPAddr))
+	require.NoError(t, err)
+	defer responder.Close()
+
+	cameras, err := Discover(context.Background(), DiscoverOptions{
+		MDNS:     true,
+		MDNSAddr: responder.Addr(),
+		MDNSWait: 200 * time.Millisecond,
+	})
+
+	require.NoError(t, err)
+	require.Len(t, cameras, 1)
+	require.Equal(t, srv.Listener.Addr().String(), cameras[0].Addr)
+	require.Equal(t, "E2", cameras[0].Model)
+	require.Equal(t, srv.Camera.Info().SN, cameras[0].SN)
+}
+
+func TestDiscoverSubnet(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	addr := srv.Listener.Addr().(*net.TCPAddr)
+	cameras, err := Discover(context.Background(), DiscoverOptions{
+		Subnets: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/30")},
+		Port:    addr.Port,
+	})
+
+	require.NoError(t, err)
+	require.Len(t, cameras, 1)
+	require.Equal(t, addr.String(), cameras[0].Addr)
+}
+
+func TestSubnetHosts(t *testing.T) {
+	hosts, err := subnetHosts(netip.MustParsePrefix("192.168.1.17/24"))
+	require.NoError(t, err)
+	require.Len(t, hosts, 254)
+	require.Equal(t, "192.168.1.1", hosts[0].String())
+	require.Equal(t, "192.168.1.254", hosts[253].String())
+
+	hosts, err = subnetHosts(netip.MustParsePrefix("10.0.0.5/32"))
+	require.NoError(t, err)
+	require.Len(t, hosts, 1)
+
+	_, err = subnetHosts(netip.MustParsePrefix("10.0.0.0/8"))
+	require.Error(t, err)
+}
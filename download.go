@@ -0,0 +1,237 @@
+package zcam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// downloadAttempts is the maximum number of attempts of a download when
+	// the camera has no RetryPolicy, every attempt resumes from the last byte
+	// received.
+	downloadAttempts = 5
+	// partSuffix is appended to the filename while downloading.
+	partSuffix = ".part"
+)
+
+// downloadBackoff is the backoff between the attempts of a download when the
+// camera has no RetryPolicy.
+var downloadBackoff = &RetryPolicy{
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     time.Second,
+}
+
+// partialDownload is the state of a download being written to a ".part" file.
+type partialDownload struct {
+	out    *os.File
+	offset int64
+	// validator is the ETag or the Last-Modified of the content, sent as
+	// If-Range when resuming, so a file changed at the camera is downloaded
+	// from the start. Without it the download can't be resumed.
+	validator string
+	// modTime is the Last-Modified of the content, kept as the modification
+	// time of the part file to resume it in a later download.
+	modTime time.Time
+}
+
+// update keeps the validator of the response.
+func (d *partialDownload) update(resp *http.Response) {
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+	t, err := http.ParseTime(lastModified)
+	if err == nil {
+		d.modTime = t
+	}
+
+	switch {
+	case etag != "" && !strings.HasPrefix(etag, "W/"):
+		d.validator = etag
+	case err == nil:
+		d.validator = lastModified
+	default:
+		d.validator = ""
+	}
+}
+
+// restart truncates the part file, to write it from the start.
+func (d *partialDownload) restart() error {
+	if err := d.out.Truncate(0); err != nil {
+		return err
+	}
+
+	if _, err := d.out.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	d.offset = 0
+	return nil
+}
+
+// download writes the content of the endpoint to filename, through a ".part"
+// file resumed if it already exists, returning the size of the file. The
+// modification time of the part file is set to the Last-Modified of the
+// content, and sent as If-Range when resuming it.
+//
+// The failed attempts are resumed up to the MaxAttempts of the RetryPolicy,
+// or downloadAttempts without it, the requests aren't retried on their own.
+func (c *Camera) download(ctx context.Context, endpoint, filename string) (int64, error) {
+	part := filename + partSuffix
+	out, err := os.OpenFile(part, os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return -1, fmt.Errorf("unable to create file %q: %w", part, err)
+	}
+
+	defer out.Close()
+
+	d := &partialDownload{out: out}
+	if d.offset, err = out.Seek(0, io.SeekEnd); err != nil {
+		return -1, err
+	}
+
+	if d.offset > 0 {
+		info, err := out.Stat()
+		if err != nil {
+			return -1, err
+		}
+
+		d.validator = info.ModTime().UTC().Format(http.TimeFormat)
+	}
+
+	attempts := downloadAttempts
+	if c.Retry != nil {
+		attempts = c.Retry.MaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := c.downloadFrom(ctx, endpoint, d)
+		if err == nil {
+			break
+		}
+
+		// the part is left for a later download to resume it
+		if !d.modTime.IsZero() {
+			os.Chtimes(part, d.modTime, d.modTime)
+		}
+
+		if ctx.Err() != nil || attempt >= attempts || !c.retryableDownload(err) {
+			return -1, err
+		}
+
+		wait := downloadBackoff.backoff(attempt)
+		if c.Retry != nil {
+			wait = c.Retry.backoff(attempt)
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return -1, err
+		}
+	}
+
+	if err := out.Close(); err != nil {
+		return -1, fmt.Errorf("unable to write file %q: %w", part, err)
+	}
+
+	if err := os.Rename(part, filename); err != nil {
+		return -1, fmt.Errorf("unable to rename file %q: %w", part, err)
+	}
+
+	return d.offset, nil
+}
+
+func (c *Camera) retryableDownload(err error) bool {
+	if c.Retry != nil {
+		return c.Retry.retryable(err)
+	}
+
+	return IsRetryable(err)
+}
+
+// downloadFrom requests the content from the offset, appending it to the
+// part file and advancing the offset with every byte written. If the camera
+// ignores the Range header, or the content changed, the part file is
+// truncated and the content written from the start.
+func (c *Camera) downloadFrom(ctx context.Context, endpoint string, d *partialDownload) error {
+	if d.offset > 0 && d.validator == "" {
+		if err := d.restart(); err != nil {
+			return err
+		}
+	}
+
+	var header http.Header
+	if d.offset > 0 {
+		header = http.Header{
+			"Range":    {fmt.Sprintf("bytes=%d-", d.offset)},
+			"If-Range": {d.validator},
+		}
+	}
+
+	resp, err := c.doMethod(ctx, http.MethodGet, endpoint, header)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.HTTPStatus == http.StatusRequestedRangeNotSatisfiable {
+		return c.downloadComplete(ctx, endpoint, d)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusPartialContent {
+		start, _, err := parseContentRange(resp)
+		if err != nil {
+			return err
+		}
+
+		if start != d.offset {
+			return fmt.Errorf("unexpected Content-Range %q, requested from %d", resp.Header.Get("Content-Range"), d.offset)
+		}
+	} else if d.offset > 0 {
+		if err := d.restart(); err != nil {
+			return err
+		}
+	}
+
+	d.update(resp)
+	n, err := io.Copy(d.out, resp.Body)
+	d.offset += n
+	return err
+}
+
+// downloadComplete handles a range not satisfiable, the existing part may be
+// already complete, otherwise the download is started over.
+func (c *Camera) downloadComplete(ctx context.Context, endpoint string, d *partialDownload) error {
+	resp, err := c.doMethod(ctx, http.MethodGet, endpoint, http.Header{
+		"Range":    {"bytes=0-0"},
+		"If-Range": {d.validator},
+	})
+	if err != nil {
+		return err
+	}
+
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusPartialContent {
+		size, err := parseContentRangeSize(resp)
+		if err != nil {
+			return err
+		}
+
+		if size == d.offset {
+			return nil
+		}
+	}
+
+	if err := d.restart(); err != nil {
+		return err
+	}
+
+	return c.downloadFrom(ctx, endpoint, d)
+}
@@ -0,0 +1,160 @@
+package zcam
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mcuadros/go-zcam-e2/zcamtest"
+	"github.com/stretchr/testify/require"
+)
+
+func newDownloadFile(t *testing.T) (*zcamtest.Server, *File, []byte) {
+	srv := zcamtest.NewServer()
+	t.Cleanup(srv.Close)
+
+	clip := zcamtest.NewClip("clip.MOV", time.Now(), 10*time.Second)
+	srv.Camera.AddFile(zcamtest.DefaultFolder, clip)
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	return srv, &File{c: cli, folder: zcamtest.DefaultFolder, file: "clip.MOV"}, clip.Data
+}
+
+func TestDownloadRetry(t *testing.T) {
+	srv, f, data := newDownloadFile(t)
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/DCIM/", Interrupt: true, InterruptAfter: 4096, Count: 2})
+
+	dst := filepath.Join(t.TempDir(), "clip.MOV")
+	n, err := f.Download(context.Background(), Original, dst)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), n)
+	require.Equal(t, 2, srv.Faults.Injected())
+
+	content, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, data, content)
+	require.NoFileExists(t, dst+partSuffix)
+}
+
+func TestDownloadRetryPolicy(t *testing.T) {
+	srv, f, _ := newDownloadFile(t)
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/DCIM/", StatusCode: http.StatusServiceUnavailable})
+
+	dst := filepath.Join(t.TempDir(), "clip.MOV")
+	_, err := f.Download(context.Background(), Original, dst)
+	require.ErrorIs(t, err, ErrBusy)
+	require.Equal(t, downloadAttempts, srv.Faults.Injected())
+
+	// the attempts of the policy replace the ones of the download
+	srv.Faults.Reset()
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/DCIM/", StatusCode: http.StatusServiceUnavailable})
+	f.c.Retry = &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	_, err = f.Download(context.Background(), Original, dst)
+	require.ErrorIs(t, err, ErrBusy)
+	require.Equal(t, downloadAttempts+3, srv.Faults.Injected())
+}
+
+func TestDownloadResume(t *testing.T) {
+	srv, f, data := newDownloadFile(t)
+	clip, _ := srv.Camera.File(zcamtest.DefaultFolder, "clip.MOV")
+
+	var mu sync.Mutex
+	var ranges []string
+	var statuses []int
+	f.c.Client.Transport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		resp, err := http.DefaultTransport.RoundTrip(r)
+		if err == nil {
+			mu.Lock()
+			ranges = append(ranges, r.Header.Get("Range"))
+			statuses = append(statuses, resp.StatusCode)
+			mu.Unlock()
+		}
+
+		return resp, err
+	})
+
+	// the part file of a previous download has the modification time of the
+	// file in the camera.
+	dst := filepath.Join(t.TempDir(), "clip.MOV")
+	require.NoError(t, os.WriteFile(dst+partSuffix, data[:3000], 0o644))
+	require.NoError(t, os.Chtimes(dst+partSuffix, clip.CreatedAt, clip.CreatedAt))
+
+	n, err := f.Download(context.Background(), Original, dst)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), n)
+	require.Equal(t, []string{"bytes=3000-"}, ranges)
+	require.Equal(t, []int{http.StatusPartialContent}, statuses)
+
+	content, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, data, content)
+
+	// a complete part file is just renamed
+	ranges, statuses = nil, nil
+	require.NoError(t, os.WriteFile(dst+partSuffix, data, 0o644))
+	require.NoError(t, os.Chtimes(dst+partSuffix, clip.CreatedAt, clip.CreatedAt))
+
+	n, err = f.Download(context.Background(), Original, dst)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), n)
+	require.Equal(t, []string{"bytes=10240-", "bytes=0-0"}, ranges)
+
+	// a part file of a different file is downloaded from the start
+	ranges, statuses = nil, nil
+	require.NoError(t, os.WriteFile(dst+partSuffix, make([]byte, 3000), 0o644))
+	require.NoError(t, os.Chtimes(dst+partSuffix, clip.CreatedAt.Add(-time.Hour), clip.CreatedAt.Add(-time.Hour)))
+
+	n, err = f.Download(context.Background(), Original, dst)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), n)
+	require.Equal(t, []int{http.StatusOK}, statuses)
+
+	content, err = os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, data, content)
+}
+
+func TestDownloadResumeChanged(t *testing.T) {
+	srv, f, _ := newDownloadFile(t)
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/DCIM/", Interrupt: true, InterruptAfter: 4096, Count: 1})
+
+	// the file is replaced at the camera while downloading
+	changed := &zcamtest.File{Name: "clip.MOV", CreatedAt: time.Now().Add(time.Hour), Data: bytes.Repeat([]byte{1}, 8000)}
+	f.c.Client.Transport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Header.Get("If-Range") != "" {
+			srv.Camera.AddFile(zcamtest.DefaultFolder, changed)
+		}
+
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	dst := filepath.Join(t.TempDir(), "clip.MOV")
+	n, err := f.Download(context.Background(), Original, dst)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(changed.Data)), n)
+
+	content, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, changed.Data, content)
+}
+
+func TestDownloadRangeNotSupported(t *testing.T) {
+	_, f, _ := newDownloadFile(t)
+
+	dst := filepath.Join(t.TempDir(), "thumbnail.jpg")
+	require.NoError(t, os.WriteFile(dst+partSuffix, make([]byte, 2000), 0o644))
+
+	n, err := f.Download(context.Background(), Thumbnail, dst)
+	require.NoError(t, err)
+	require.Equal(t, int64(512), n)
+
+	info, err := os.Stat(dst)
+	require.NoError(t, err)
+	require.Equal(t, int64(512), info.Size())
+}
@@ -0,0 +1,93 @@
+package zcam
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrNoCard is returned by the card commands when there is no storage
+	// card.
+	ErrNoCard = errors.New("no card present")
+	// ErrBusy is returned when the camera responds with a 503 HTTP status
+	// code, not being able to process the command at the moment.
+	ErrBusy = errors.New("camera busy")
+	// ErrSessionOwnedByOther is returned when another client holds the
+	// control session of the camera.
+	ErrSessionOwnedByOther = errors.New("session owned by other client")
+	// ErrNotSupported is returned by GetSetting when the setting is not
+	// supported by the camera.
+	ErrNotSupported = errors.New("not supported")
+	// ErrReadOnlySetting is returned by the client-side validation when
+	// trying to change a read-only setting.
+	ErrReadOnlySetting = errors.New("read-only setting")
+	// ErrInvalidValue is returned by the client-side validation when a value
+	// is not accepted by a setting.
+	ErrInvalidValue = errors.New("invalid value")
+	// ErrUnauthorized is returned when the camera requires to log in, and
+	// no credentials were provided or the login did not succeed.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrInvalidCredentials is returned when the camera rejects the username
+	// and password at login.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// APIError is returned when the camera responds with an unexpected HTTP
+// status code or with a non-zero code in the JSON body. It wraps one of the
+// sentinel errors when the cause is recognized by the HTTP status code, to be
+// used with errors.Is. The camera reports every failed command with the same
+// non-zero code and an empty description, so these don't wrap any.
+type APIError struct {
+	// Endpoint is the requested endpoint, including the query string.
+	Endpoint string
+	// HTTPStatus is the HTTP status code of the response.
+	HTTPStatus int
+	// Code, Desc and Msg are the fields of the JSON response, if any.
+	Code int
+	Desc string
+	Msg  string
+}
+
+func (e *APIError) Error() string {
+	if e.HTTPStatus != http.StatusOK {
+		return fmt.Sprintf("unexpected response code: %d at %s", e.HTTPStatus, e.Endpoint)
+	}
+
+	msg := fmt.Sprintf("unexpected code %d at %s", e.Code, e.Endpoint)
+	if e.Desc != "" {
+		msg += ": " + e.Desc
+	}
+
+	if e.Msg != "" {
+		msg += ": " + e.Msg
+	}
+
+	return msg
+}
+
+// Unwrap returns the sentinel error matching the cause of the error, nil if
+// the cause is unknown.
+func (e *APIError) Unwrap() error {
+	switch {
+	case strings.HasPrefix(e.Endpoint, "/login") && (e.HTTPStatus == http.StatusOK ||
+		e.HTTPStatus == http.StatusUnauthorized || e.HTTPStatus == http.StatusForbidden):
+		return ErrInvalidCredentials
+	case e.HTTPStatus == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case e.HTTPStatus == http.StatusConflict && strings.HasPrefix(e.Endpoint, "/ctrl/session"):
+		return ErrSessionOwnedByOther
+	case e.HTTPStatus == http.StatusServiceUnavailable:
+		return ErrBusy
+	}
+
+	return nil
+}
+
+// isRejected reports whether the camera rejected the command, responding
+// with a non-zero code.
+func isRejected(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.HTTPStatus == http.StatusOK && apiErr.Code != 0
+}
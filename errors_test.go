@@ -0,0 +1,94 @@
+package zcam
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+	"github.com/mcuadros/go-zcam-e2/zcamtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIErrorSentinels(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	ctx := context.Background()
+
+	srv.Camera.SetSessionOccupied(true)
+	err := cli.StartSession(ctx)
+	require.ErrorIs(t, err, ErrSessionOwnedByOther)
+
+	srv.Camera.SetCardPresent(false)
+	err = cli.StartVideoRecord(ctx)
+	require.ErrorIs(t, err, ErrNoCard)
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, "/ctrl/rec?action=start", apiErr.Endpoint)
+	require.Equal(t, http.StatusOK, apiErr.HTTPStatus)
+	require.Equal(t, -1, apiErr.Code)
+
+	present, err := cli.CheckCardPresence(ctx)
+	require.NoError(t, err)
+	require.False(t, present)
+}
+
+func TestAPIErrorUnknownCause(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	ctx := context.Background()
+
+	// the camera rejects the commands with the same code, whatever the cause
+	err := cli.SetSetting(ctx, settings.BatterySetting, 10)
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, -1, apiErr.Code)
+	require.Empty(t, apiErr.Desc)
+	require.Nil(t, apiErr.Unwrap())
+
+	require.NoError(t, cli.StartVideoRecord(ctx))
+	err = cli.FormatCard(ctx)
+	require.True(t, isRejected(err))
+	require.NotErrorIs(t, err, ErrNoCard)
+	require.NoError(t, cli.StopVideoRecord(ctx))
+}
+
+func TestGetSettingNotSupported(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	ctx := context.Background()
+
+	_, err := cli.GetSetting(ctx, settings.VignetteSetting)
+	require.ErrorIs(t, err, ErrNotSupported)
+
+	// a setting rejected once is not considered unsupported
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/ctrl/get?k=iso", Code: -1, Count: 1})
+	v, err := cli.GetSetting(ctx, settings.ISOSetting)
+	require.NoError(t, err)
+	require.Equal(t, "Auto", v.Value)
+}
+
+func TestAPIErrorHTTPStatus(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	srv.Faults.Add(zcamtest.Fault{StatusCode: http.StatusServiceUnavailable})
+	cli := NewCamera(srv.Listener.Addr().String())
+
+	_, err := cli.GetCameraInfo(context.Background())
+	require.ErrorIs(t, err, ErrBusy)
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusServiceUnavailable, apiErr.HTTPStatus)
+	require.Equal(t, "unexpected response code: 503 at /info", apiErr.Error())
+}
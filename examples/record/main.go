@@ -27,19 +27,21 @@ func main() {
 	}()
 
 	log.Printf("starting recording for 1sec")
-	f, err := cli.VideoRecord(ctx, time.Second)
+	r, err := cli.Record(ctx, time.Second)
 	if err != nil {
 		log.Fatalf("error recording: %s", err)
 	}
 
-	log.Printf("downloading file: %s", f.Filename())
-	bytes, err := f.Download(ctx, zcam.Original, f.Filename())
-	if err != nil {
-		log.Fatal(err)
-	}
+	for _, f := range r.Files() {
+		log.Printf("downloading file: %s", f.Filename())
+		bytes, err := f.Download(ctx, zcam.Original, f.Filename())
+		if err != nil {
+			log.Fatal(err)
+		}
 
-	log.Printf("file %s downloaded, size %d bytes", f.Filename(), bytes)
-	if err := f.Delete(ctx); err != nil {
-		log.Fatal(err)
+		log.Printf("file %s downloaded, size %d bytes", f.Filename(), bytes)
+		if err := f.Delete(ctx); err != nil {
+			log.Fatal(err)
+		}
 	}
 }
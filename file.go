@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -30,6 +29,13 @@ const (
 	Screennail Format = "screennail"
 )
 
+// formatActions are the query strings of the file endpoint by format.
+var formatActions = map[Format]string{
+	Original:   "",
+	Thumbnail:  "?act=thm",
+	Screennail: "?act=scr",
+}
+
 type File struct {
 	c            *Camera
 	folder, file string
@@ -39,7 +45,12 @@ type File struct {
 // NewFileFromValueSetting returns a new File from a SettingValue, usually the
 // settings.LastFileNameSetting setting.
 func NewFileFromValueSetting(c *Camera, v *SettingValue) (*File, error) {
-	return NewFile(c, v.MustValueString())
+	path, err := v.StringValue()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFile(c, path)
 }
 
 // NewFile returns a new file for a given path.
@@ -115,28 +126,36 @@ func (f *File) CreatedAt(ctx context.Context) (time.Time, error) {
 	return time.Unix(int64(unix), 0), nil
 }
 
+// Size returns the size of the file in bytes, see Camera.GetFileSize.
+func (f *File) Size(ctx context.Context) (int64, error) {
+	return f.c.GetFileSize(ctx, f.folder, f.file)
+}
+
 func (f *File) Delete(ctx context.Context) error {
 	defer f.Close()
 	return f.c.DeleteFile(ctx, f.folder, f.file)
 }
 
-// Download copy the camera file to a local file, return the downloaded bytes.
-// It opens and close this File,
+// Download copies the camera file to a local file, returning its size. The
+// data is written to filename plus ".part", renamed to filename once
+// complete. If the ".part" file already exists, the download is resumed
+// from its end using a Range request, and if the transfer is interrupted it's
+// retried from the last byte received, up to 5 attempts. The file is
+// downloaded from the start if it changed at the camera since the ".part"
+// file was written.
 func (f *File) Download(ctx context.Context, format Format, filename string) (int64, error) {
-	if err := f.Open(ctx, format); err != nil {
-		return -1, fmt.Errorf("unable to open file %q in folder %q: %w", f.file, f.folder, err)
+	act, ok := formatActions[format]
+	if !ok {
+		return -1, ErrUnknownFormat
 	}
 
-	defer f.Close()
-
-	file, err := os.Create(filename)
+	endpoint := fmt.Sprintf(RootFolder+"%s/%s%s", f.folder, f.file, act)
+	n, err := f.c.download(ctx, endpoint, filename)
 	if err != nil {
-		return -1, fmt.Errorf("unable to create file %q: %w", filename, err)
+		return -1, fmt.Errorf("unable to download file %q in folder %q: %w", f.file, f.folder, err)
 	}
 
-	defer file.Close()
-
-	return io.Copy(file, f)
+	return n, nil
 }
 
 type fileListResponse struct {
@@ -239,25 +258,103 @@ func (c *Camera) GetFileInfo(ctx context.Context, folder, filename string) (*Fil
 	return c.sendFileInfoRequest(ctx, endpoint)
 }
 
-func (c *Camera) getReader(ctx context.Context, endpoint string) (io.ReadCloser, error) {
-	url := fmt.Sprintf("%s%s", c.baseURL, endpoint)
-	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("unable to create GET request: %w", err)
+// GetFileSize returns the size in bytes of a file, from the Content-Length of
+// a HEAD request, or from the Content-Range of a request of the first byte if
+// HEAD is not supported.
+func (c *Camera) GetFileSize(ctx context.Context, folder, filename string) (int64, error) {
+	endpoint := fmt.Sprintf(RootFolder+"%s/%s", folder, filename)
+
+	var size int64
+	err := c.retry(ctx, endpoint, func() error {
+		resp, err := c.doMethod(ctx, http.MethodHead, endpoint, nil)
+		if err != nil {
+			return err
+		}
+
+		resp.Body.Close()
+		size = resp.ContentLength
+		return nil
+	})
+
+	if err == nil && size >= 0 {
+		return size, nil
+	}
+
+	var apiErr *APIError
+	if err != nil && !errors.As(err, &apiErr) {
+		return -1, err
 	}
 
-	resp, err := c.Client.Do(request)
+	resp, err := c.getResponse(ctx, endpoint, http.Header{"Range": {"bytes=0-0"}})
 	if err != nil {
-		return nil, fmt.Errorf("error making GET request to %s: %w", url, err)
+		return -1, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected response code: %d", resp.StatusCode)
+	resp.Body.Close()
+	return parseContentRangeSize(resp)
+}
+
+// parseContentRangeSize returns the complete length of a response to a Range
+// request, such as 1024 for "bytes 0-0/1024".
+func parseContentRangeSize(resp *http.Response) (int64, error) {
+	if resp.StatusCode == http.StatusOK {
+		return resp.ContentLength, nil
+	}
+
+	_, size, err := parseContentRange(resp)
+	return size, err
+}
+
+// parseContentRange returns the first byte and the complete length of a
+// partial response, such as 512 and 1024 for "bytes 512-1023/1024".
+func parseContentRange(resp *http.Response) (start, size int64, err error) {
+	cr := resp.Header.Get("Content-Range")
+	r, total, ok := strings.Cut(strings.TrimPrefix(cr, "bytes "), "/")
+	first, _, ok2 := strings.Cut(r, "-")
+	if !ok || !ok2 || total == "*" {
+		return -1, -1, fmt.Errorf("unexpected Content-Range %q", cr)
+	}
+
+	if start, err = strconv.ParseInt(first, 10, 64); err != nil {
+		return -1, -1, fmt.Errorf("unexpected Content-Range %q", cr)
+	}
+
+	if size, err = strconv.ParseInt(total, 10, 64); err != nil {
+		return -1, -1, fmt.Errorf("unexpected Content-Range %q", cr)
+	}
+
+	return start, size, nil
+}
+
+// getReader performs a GET request to the given endpoint and returns the
+// response body, the request is retried according to the RetryPolicy until
+// the response is received.
+func (c *Camera) getReader(ctx context.Context, endpoint string) (io.ReadCloser, error) {
+	resp, err := c.getResponse(ctx, endpoint, nil)
+	if err != nil {
+		return nil, err
 	}
 
 	return resp.Body, nil
 }
 
+// getResponse performs a GET request with the given headers, as getReader
+// does, returning the whole response.
+func (c *Camera) getResponse(ctx context.Context, endpoint string, header http.Header) (*http.Response, error) {
+	var resp *http.Response
+	err := c.retry(ctx, endpoint, func() error {
+		var err error
+		resp, err = c.doMethod(ctx, http.MethodGet, endpoint, header)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
 func (c *Camera) sendFileRequest(ctx context.Context, endpoint string) (*fileListResponse, error) {
 	body, err := c.get(ctx, endpoint)
 	if err != nil {
@@ -270,7 +367,7 @@ func (c *Camera) sendFileRequest(ctx context.Context, endpoint string) (*fileLis
 	}
 
 	if r.Code != 0 {
-		return nil, fmt.Errorf("unexpected code response %d", r.Code)
+		return nil, &APIError{Endpoint: endpoint, HTTPStatus: http.StatusOK, Code: r.Code, Desc: r.Desc}
 	}
 
 	return &r, nil
@@ -288,7 +385,7 @@ func (c *Camera) sendFileInfoRequest(ctx context.Context, endpoint string) (*Fil
 	}
 
 	if r.Code != 0 {
-		return nil, fmt.Errorf("unexpected code response %d", r.Code)
+		return nil, &APIError{Endpoint: endpoint, HTTPStatus: http.StatusOK, Code: r.Code, Desc: r.Desc, Msg: r.Msg}
 	}
 
 	return &r, nil
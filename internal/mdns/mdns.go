@@ -0,0 +1,240 @@
+// Package mdns implements the minimal subset of the DNS wire format required
+// to perform and answer DNS-SD queries over multicast DNS.
+package mdns
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// DefaultAddr is the IPv4 mDNS multicast group address.
+const DefaultAddr = "224.0.0.251:5353"
+
+// Record types supported.
+const (
+	TypeA    uint16 = 1
+	TypePTR  uint16 = 12
+	TypeTXT  uint16 = 16
+	TypeAAAA uint16 = 28
+	TypeSRV  uint16 = 33
+)
+
+const (
+	classIN      = 1
+	unicastBit   = 0x8000
+	flagResponse = 0x8400
+	maxPointers  = 16
+)
+
+var errMalformed = errors.New("malformed DNS message")
+
+// Question is a query for the given name and type.
+type Question struct {
+	Name string
+	Type uint16
+}
+
+// Record is a resource record, only the fields matching the Type are set.
+type Record struct {
+	Name string
+	Type uint16
+	TTL  uint32
+	// Target is the pointed name of PTR records and the host of SRV records.
+	Target string
+	// Port of SRV records.
+	Port uint16
+	// IP of A and AAAA records.
+	IP net.IP
+}
+
+// Message is a decoded DNS message.
+type Message struct {
+	Response  bool
+	Questions []Question
+	Records   []Record
+}
+
+// Query returns an encoded query for the given name and type, requesting an
+// unicast response.
+func Query(name string, qtype uint16) []byte {
+	buf := make([]byte, 12)
+	binary.BigEndian.PutUint16(buf[4:], 1)
+	buf = appendName(buf, name)
+	buf = binary.BigEndian.AppendUint16(buf, qtype)
+	return binary.BigEndian.AppendUint16(buf, classIN|unicastBit)
+}
+
+// Response returns an encoded authoritative response with the given records
+// as answers.
+func Response(records []Record) ([]byte, error) {
+	buf := make([]byte, 12)
+	binary.BigEndian.PutUint16(buf[2:], flagResponse)
+	binary.BigEndian.PutUint16(buf[6:], uint16(len(records)))
+
+	for _, r := range records {
+		var rdata []byte
+		switch r.Type {
+		case TypePTR:
+			rdata = appendName(nil, r.Target)
+		case TypeSRV:
+			rdata = make([]byte, 6)
+			binary.BigEndian.PutUint16(rdata[4:], r.Port)
+			rdata = appendName(rdata, r.Target)
+		case TypeA:
+			rdata = r.IP.To4()
+		case TypeAAAA:
+			rdata = r.IP.To16()
+		default:
+			return nil, fmt.Errorf("unsupported record type %d", r.Type)
+		}
+
+		buf = appendName(buf, r.Name)
+		buf = binary.BigEndian.AppendUint16(buf, r.Type)
+		buf = binary.BigEndian.AppendUint16(buf, classIN)
+		buf = binary.BigEndian.AppendUint32(buf, r.TTL)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(rdata)))
+		buf = append(buf, rdata...)
+	}
+
+	return buf, nil
+}
+
+// Parse decodes a DNS message, the records of all the sections are returned
+// together and the records of unsupported types are skipped.
+func Parse(msg []byte) (*Message, error) {
+	if len(msg) < 12 {
+		return nil, errMalformed
+	}
+
+	m := &Message{Response: msg[2]&0x80 != 0}
+	qd := int(binary.BigEndian.Uint16(msg[4:]))
+	rr := int(binary.BigEndian.Uint16(msg[6:])) +
+		int(binary.BigEndian.Uint16(msg[8:])) +
+		int(binary.BigEndian.Uint16(msg[10:]))
+
+	off := 12
+	for i := 0; i < qd; i++ {
+		name, n, err := readName(msg, off)
+		if err != nil {
+			return nil, err
+		}
+
+		off = n
+		if off+4 > len(msg) {
+			return nil, errMalformed
+		}
+
+		m.Questions = append(m.Questions, Question{
+			Name: name,
+			Type: binary.BigEndian.Uint16(msg[off:]),
+		})
+		off += 4
+	}
+
+	for i := 0; i < rr; i++ {
+		name, n, err := readName(msg, off)
+		if err != nil {
+			return nil, err
+		}
+
+		off = n
+		if off+10 > len(msg) {
+			return nil, errMalformed
+		}
+
+		r := Record{
+			Name: name,
+			Type: binary.BigEndian.Uint16(msg[off:]),
+			TTL:  binary.BigEndian.Uint32(msg[off+4:]),
+		}
+
+		length := int(binary.BigEndian.Uint16(msg[off+8:]))
+		off += 10
+		if off+length > len(msg) {
+			return nil, errMalformed
+		}
+
+		rdata := msg[off : off+length]
+		switch r.Type {
+		case TypePTR:
+			if r.Target, _, err = readName(msg, off); err != nil {
+				return nil, err
+			}
+		case TypeSRV:
+			if length < 6 {
+				return nil, errMalformed
+			}
+
+			r.Port = binary.BigEndian.Uint16(rdata[4:])
+			if r.Target, _, err = readName(msg, off+6); err != nil {
+				return nil, err
+			}
+		case TypeA, TypeAAAA:
+			r.IP = net.IP(append([]byte(nil), rdata...))
+		default:
+			off += length
+			continue
+		}
+
+		off += length
+		m.Records = append(m.Records, r)
+	}
+
+	return m, nil
+}
+
+func appendName(buf []byte, name string) []byte {
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == "" {
+			continue
+		}
+
+		buf = append(buf, byte(len(label)))
+		buf = append(buf, label...)
+	}
+
+	return append(buf, 0)
+}
+
+// readName reads a name at the given offset, following the compression
+// pointers, it returns the offset after the name.
+func readName(msg []byte, off int) (string, int, error) {
+	var labels []string
+	end := -1
+	for jumps := 0; ; {
+		if off >= len(msg) {
+			return "", 0, errMalformed
+		}
+
+		length := int(msg[off])
+		switch {
+		case length == 0:
+			if end < 0 {
+				end = off + 1
+			}
+
+			return strings.Join(labels, ".") + ".", end, nil
+		case length&0xC0 == 0xC0:
+			if off+1 >= len(msg) || jumps >= maxPointers {
+				return "", 0, errMalformed
+			}
+
+			if end < 0 {
+				end = off + 2
+			}
+
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3FFF)
+			jumps++
+		default:
+			if off+1+length > len(msg) {
+				return "", 0, errMalformed
+			}
+
+			labels = append(labels, string(msg[off+1:off+1+length]))
+			off += 1 + length
+		}
+	}
+}
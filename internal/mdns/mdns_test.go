@@ -0,0 +1,61 @@
+package mdns
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuery(t *testing.T) {
+	m, err := Parse(Query("_http._tcp.local.", TypePTR))
+	require.NoError(t, err)
+	require.False(t, m.Response)
+	require.Equal(t, []Question{{Name: "_http._tcp.local.", Type: TypePTR}}, m.Questions)
+}
+
+func TestResponse(t *testing.T) {
+	records := []Record{
+		{Name: "_http._tcp.local.", Type: TypePTR, TTL: 120, Target: "E2._http._tcp.local."},
+		{Name: "E2._http._tcp.local.", Type: TypeSRV, TTL: 120, Target: "e2.local.", Port: 80},
+		{Name: "e2.local.", Type: TypeA, TTL: 120, IP: net.IPv4(192, 168, 1, 10).To4()},
+		{Name: "e2.local.", Type: TypeAAAA, TTL: 120, IP: net.ParseIP("fe80::1")},
+	}
+
+	msg, err := Response(records)
+	require.NoError(t, err)
+
+	m, err := Parse(msg)
+	require.NoError(t, err)
+	require.True(t, m.Response)
+	require.Equal(t, records, m.Records)
+}
+
+func TestParseCompressed(t *testing.T) {
+	msg := []byte{
+		0, 0, 0x84, 0, 0, 0, 0, 1, 0, 0, 0, 0,
+		// e2.local. at offset 12
+		2, 'e', '2', 5, 'l', 'o', 'c', 'a', 'l', 0,
+		0, 1, 0, 1, 0, 0, 0, 120, 0, 4, 10, 0, 0, 1,
+	}
+
+	// a second A record pointing to the name at offset 12
+	msg[7] = 2
+	msg = append(msg, 0xC0, 12, 0, 1, 0, 1, 0, 0, 0, 120, 0, 4, 10, 0, 0, 2)
+
+	m, err := Parse(msg)
+	require.NoError(t, err)
+	require.Len(t, m.Records, 2)
+	require.Equal(t, "e2.local.", m.Records[1].Name)
+	require.Equal(t, "10.0.0.2", m.Records[1].IP.String())
+}
+
+func TestParseMalformed(t *testing.T) {
+	_, err := Parse([]byte{0, 0})
+	require.Error(t, err)
+
+	// pointer loop
+	msg := []byte{0, 0, 0x84, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0xC0, 12}
+	_, err = Parse(msg)
+	require.Error(t, err)
+}
@@ -0,0 +1,202 @@
+package zcam
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ListOptions filters the files returned by ListFilesDetailed, the zero
+// value returns every file.
+type ListOptions struct {
+	// Extensions are the accepted extensions, such as ".MOV", compared
+	// case-insensitively. Empty accepts any.
+	Extensions []string
+	// Since and Until limit the creation time, inclusive, zero means no
+	// limit.
+	Since, Until time.Time
+	// MinDuration excludes the videos shorter than it, and the stills.
+	MinDuration time.Duration
+	// Concurrency is the maximum number of concurrent requests, by default
+	// Camera.Concurrency.
+	Concurrency int
+}
+
+// matchesName reports whether the file may match by its name, before
+// requesting any detail: the extension and, with MinDuration, not being a
+// still.
+func (o *ListOptions) matchesName(filename string) bool {
+	if o.MinDuration > 0 && isStill(filename) {
+		return false
+	}
+
+	if len(o.Extensions) == 0 {
+		return true
+	}
+
+	ext := path.Ext(filename)
+	for _, e := range o.Extensions {
+		if strings.EqualFold(ext, e) || strings.EqualFold(ext, "."+e) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (o *ListOptions) matchesTime(createdAt time.Time) bool {
+	if !o.Since.IsZero() && createdAt.Before(o.Since) {
+		return false
+	}
+
+	return o.Until.IsZero() || !createdAt.After(o.Until)
+}
+
+func (o *ListOptions) matchesDuration(f *DetailedFile) bool {
+	return o.MinDuration == 0 || (f.Info != nil && f.Duration() >= o.MinDuration)
+}
+
+// DetailedFile is a File along with its details, returned by
+// ListFilesDetailed.
+type DetailedFile struct {
+	File *File
+	// Info is the media information of the videos, nil for the stills.
+	Info      *FileInformation
+	CreatedAt time.Time
+	// Size in bytes.
+	Size int64
+}
+
+// Duration returns the duration of the video, zero for the stills.
+func (f *DetailedFile) Duration() time.Duration {
+	if f.Info == nil {
+		return 0
+	}
+
+	return time.Duration(f.Info.Duration) * time.Millisecond
+}
+
+// ListFilesDetailed lists the files in a specific folder, as ListFiles does,
+// retrieving the media information, the creation time and the size of every
+// file, making at most opts.Concurrency requests at the same time. The files
+// not matching opts are excluded, opts may be nil. The details are requested
+// in the order the filters need them, so the rest of the details of an
+// excluded file are not requested.
+func (c *Camera) ListFilesDetailed(ctx context.Context, folder string, opts *ListOptions) ([]*DetailedFile, error) {
+	if opts == nil {
+		opts = &ListOptions{}
+	}
+
+	files, err := c.ListFiles(ctx, folder)
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = c.Concurrency
+	}
+
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		detailed = make([]*DetailedFile, len(files))
+	)
+
+	sem := make(chan struct{}, concurrency)
+	for i, f := range files {
+		if !opts.matchesName(f.file) {
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, f *File) {
+			defer func() { <-sem; wg.Done() }()
+
+			d, err := c.fileDetails(ctx, f, opts)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+				return
+			}
+
+			detailed[i] = d
+		}(i, f)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var result []*DetailedFile
+	for _, d := range detailed {
+		if d != nil {
+			result = append(result, d)
+		}
+	}
+
+	return result, nil
+}
+
+// fileDetails returns the details of the file, nil if it doesn't match opts.
+func (c *Camera) fileDetails(ctx context.Context, f *File, opts *ListOptions) (*DetailedFile, error) {
+	d := &DetailedFile{File: f}
+
+	var err error
+	d.CreatedAt, err = f.CreatedAt(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving creation time of %s: %w", f.file, err)
+	}
+
+	if !opts.matchesTime(d.CreatedAt) {
+		return nil, nil
+	}
+
+	if !isStill(f.file) {
+		d.Info, err = f.Info(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving information of %s: %w", f.file, err)
+		}
+	}
+
+	if !opts.matchesDuration(d) {
+		return nil, nil
+	}
+
+	d.Size, err = f.Size(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving size of %s: %w", f.file, err)
+	}
+
+	return d, nil
+}
@@ -0,0 +1,117 @@
+package zcam
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mcuadros/go-zcam-e2/zcamtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListFilesDetailed(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	srv.Camera.AddFile(zcamtest.DefaultFolder, zcamtest.NewClip("A.MOV", created, 10*time.Second))
+	srv.Camera.AddFile(zcamtest.DefaultFolder, zcamtest.NewClip("B.MOV", created.Add(time.Hour), 2*time.Second))
+	srv.Camera.AddFile(zcamtest.DefaultFolder, zcamtest.NewClip("C.MP4", created.Add(2*time.Hour), 10*time.Second))
+	srv.Camera.AddFile(zcamtest.DefaultFolder, &zcamtest.File{Name: "D.JPG", CreatedAt: created, Data: make([]byte, 100)})
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	ctx := context.Background()
+
+	files, err := cli.ListFilesDetailed(ctx, zcamtest.DefaultFolder, nil)
+	require.NoError(t, err)
+	require.Len(t, files, 4)
+
+	require.Equal(t, "A.MOV", files[0].File.Filename())
+	require.Equal(t, 3840, files[0].Info.Width)
+	require.Equal(t, 10*time.Second, files[0].Duration())
+	require.True(t, created.Equal(files[0].CreatedAt))
+	require.Equal(t, int64(10*1024), files[0].Size)
+
+	require.Equal(t, "D.JPG", files[3].File.Filename())
+	require.Nil(t, files[3].Info)
+	require.Equal(t, int64(100), files[3].Size)
+
+	files, err = cli.ListFilesDetailed(ctx, zcamtest.DefaultFolder, &ListOptions{
+		Extensions:  []string{".mov", "mp4"},
+		Until:       created.Add(90 * time.Minute),
+		MinDuration: 5 * time.Second,
+		Concurrency: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "A.MOV", files[0].File.Filename())
+
+	files, err = cli.ListFilesDetailed(ctx, zcamtest.DefaultFolder, &ListOptions{Since: created.Add(time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+}
+
+func TestListFilesDetailedRequests(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	srv.Camera.AddFile(zcamtest.DefaultFolder, zcamtest.NewClip("A.MOV", created, 10*time.Second))
+	srv.Camera.AddFile(zcamtest.DefaultFolder, zcamtest.NewClip("B.MOV", created.Add(2*time.Hour), 10*time.Second))
+	srv.Camera.AddFile(zcamtest.DefaultFolder, zcamtest.NewClip("C.MOV", created, 2*time.Second))
+	srv.Camera.AddFile(zcamtest.DefaultFolder, &zcamtest.File{Name: "D.JPG", CreatedAt: created, Data: make([]byte, 100)})
+
+	var mu sync.Mutex
+	requests := make(map[string][]string)
+	cli := NewCamera(srv.Listener.Addr().String())
+	cli.Client.Transport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if name := path.Base(r.URL.Path); strings.Contains(name, ".") {
+			mu.Lock()
+			requests[name] = append(requests[name], r.URL.RawQuery)
+			mu.Unlock()
+		}
+
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	files, err := cli.ListFilesDetailed(context.Background(), zcamtest.DefaultFolder, &ListOptions{
+		Until:       created.Add(time.Hour),
+		MinDuration: 5 * time.Second,
+	})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "A.MOV", files[0].File.Filename())
+	require.Equal(t, int64(10*1024), files[0].Size)
+
+	require.Len(t, requests["A.MOV"], 3)
+	require.Equal(t, []string{"act=ct"}, requests["B.MOV"])
+	require.Equal(t, []string{"act=ct", "act=info"}, requests["C.MOV"])
+	require.Empty(t, requests["D.JPG"])
+}
+
+func TestGetFileSizeRangeFallback(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	srv.Camera.AddFile(zcamtest.DefaultFolder, zcamtest.NewClip("A.MOV", time.Now(), 3*time.Second))
+
+	var methods []string
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		methods = append(methods, r.Method)
+		if r.Method == http.MethodHead {
+			return &http.Response{StatusCode: http.StatusMethodNotAllowed, Body: http.NoBody, Request: r}, nil
+		}
+
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	cli := NewCamera(srv.Listener.Addr().String(), WithTransport(transport))
+	size, err := cli.GetFileSize(context.Background(), zcamtest.DefaultFolder, "A.MOV")
+	require.NoError(t, err)
+	require.Equal(t, int64(3*1024), size)
+	require.Equal(t, []string{http.MethodHead, http.MethodGet}, methods)
+}
@@ -0,0 +1,175 @@
+package zcam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidTransition is returned by EnsureMode when the target mode can't
+// be reached from the current one, such as while recording.
+var ErrInvalidTransition = errors.New("invalid working mode transition")
+
+// WorkingMode is the working mode of the camera, as sent to switch modes and
+// as reported by QueryWorkingMode.
+type WorkingMode string
+
+// Camera mode constants
+const (
+	// VideoRecordWorkingMode video record mode
+	VideoRecordWorkingMode WorkingMode = "rec"
+	// PlaybackWorkingMode playback mode
+	PlaybackWorkingMode WorkingMode = "pb"
+	// StandbyWorkingMode standby mode
+	StandbyWorkingMode WorkingMode = "standby"
+	// ExitStandbyWorkingMode exit_standby, it's an action switching back to
+	// the video record mode, never reported by the camera.
+	ExitStandbyWorkingMode WorkingMode = "exit_standby"
+	// VideoRecordTimeLapseWorkingMode video timelapse record
+	VideoRecordTimeLapseWorkingMode WorkingMode = "rec_tl"
+	// VideoRecordingWorkingMode video record mode while recording, only
+	// reported by the camera.
+	VideoRecordingWorkingMode WorkingMode = "rec_ing"
+	// VideoRecordingTimeLapseWorkingMode video timelapse record mode while
+	// recording, only reported by the camera.
+	VideoRecordingTimeLapseWorkingMode WorkingMode = "rec_tl_ing"
+	// VideoRecordStoppingWorkingMode while the files of a recording are
+	// being written, only reported by the camera.
+	VideoRecordStoppingWorkingMode WorkingMode = "rec_stopping"
+)
+
+// modeTransition is a change of mode, performed by sending the action.
+type modeTransition struct {
+	action WorkingMode
+	to     WorkingMode
+}
+
+// modeTransitions are the valid transitions from every mode, the recording
+// modes have none since the recording needs to be stopped first.
+var modeTransitions = map[WorkingMode][]modeTransition{
+	VideoRecordWorkingMode: {
+		{PlaybackWorkingMode, PlaybackWorkingMode},
+		{VideoRecordTimeLapseWorkingMode, VideoRecordTimeLapseWorkingMode},
+		{StandbyWorkingMode, StandbyWorkingMode},
+	},
+	PlaybackWorkingMode: {
+		{VideoRecordWorkingMode, VideoRecordWorkingMode},
+	},
+	VideoRecordTimeLapseWorkingMode: {
+		{VideoRecordWorkingMode, VideoRecordWorkingMode},
+	},
+	StandbyWorkingMode: {
+		{ExitStandbyWorkingMode, VideoRecordWorkingMode},
+	},
+}
+
+// modePath returns the shortest list of transitions from one mode to the
+// other, false if the target is not reachable.
+func modePath(from, to WorkingMode) ([]modeTransition, bool) {
+	paths := map[WorkingMode][]modeTransition{from: nil}
+	queue := []WorkingMode{from}
+	for len(queue) != 0 {
+		m := queue[0]
+		queue = queue[1:]
+		if m == to {
+			return paths[m], true
+		}
+
+		for _, t := range modeTransitions[m] {
+			if _, ok := paths[t.to]; ok {
+				continue
+			}
+
+			paths[t.to] = append(append([]modeTransition(nil), paths[m]...), t)
+			queue = append(queue, t.to)
+		}
+	}
+
+	return nil, false
+}
+
+// modePollInterval is the wait between the queries of EnsureMode.
+const modePollInterval = 100 * time.Millisecond
+
+// ChangeWorkingMode switches the camera's working mode based on the provided constant
+//
+// Deprecated: use SetWorkingMode, decoding the response, or EnsureMode.
+func (c *Camera) ChangeWorkingMode(ctx context.Context, mode WorkingMode) (string, error) {
+	body, err := c.get(ctx, fmt.Sprintf("/ctrl/mode?action=%s", mode))
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
+
+// SetWorkingMode sends the command switching to the given mode, the camera
+// may take a while to report the new mode, see EnsureMode.
+func (c *Camera) SetWorkingMode(ctx context.Context, mode WorkingMode) error {
+	return c.sendControlRequest(ctx, fmt.Sprintf("/ctrl/mode?action=%s", mode))
+}
+
+// QueryWorkingMode queries the current working mode of the camera.
+func (c *Camera) QueryWorkingMode(ctx context.Context) (WorkingMode, error) {
+	endpoint := "/ctrl/mode?action=query"
+	body, err := c.get(ctx, endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	r, err := decodeBasicResponse(endpoint, body)
+	if err != nil {
+		return "", err
+	}
+
+	return WorkingMode(r.Msg), nil
+}
+
+// EnsureMode switches the camera to the given mode, performing the needed
+// transitions, such as leaving the standby before switching to playback, and
+// waiting until the camera reports every intermediate mode. It returns an
+// error matching ErrInvalidTransition if the mode can't be reached, the
+// context should have a deadline since the camera may never report it.
+func (c *Camera) EnsureMode(ctx context.Context, mode WorkingMode) error {
+	current, err := c.QueryWorkingMode(ctx)
+	if err != nil {
+		return err
+	}
+
+	path, ok := modePath(current, mode)
+	if !ok {
+		return fmt.Errorf("%w: from %s to %s", ErrInvalidTransition, current, mode)
+	}
+
+	for _, t := range path {
+		if err := c.SetWorkingMode(ctx, t.action); err != nil {
+			return fmt.Errorf("error switching to %s: %w", t.to, err)
+		}
+
+		if err := c.waitMode(ctx, t.to); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Camera) waitMode(ctx context.Context, mode WorkingMode) error {
+	for {
+		current, err := c.QueryWorkingMode(ctx)
+		if err != nil {
+			return err
+		}
+
+		if current == mode {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for %s mode, camera in %s: %w", mode, current, ctx.Err())
+		case <-time.After(modePollInterval):
+		}
+	}
+}
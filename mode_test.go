@@ -0,0 +1,55 @@
+package zcam
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mcuadros/go-zcam-e2/zcamtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModePath(t *testing.T) {
+	path, ok := modePath(StandbyWorkingMode, PlaybackWorkingMode)
+	require.True(t, ok)
+	require.Equal(t, []modeTransition{
+		{ExitStandbyWorkingMode, VideoRecordWorkingMode},
+		{PlaybackWorkingMode, PlaybackWorkingMode},
+	}, path)
+
+	path, ok = modePath(PlaybackWorkingMode, PlaybackWorkingMode)
+	require.True(t, ok)
+	require.Empty(t, path)
+
+	_, ok = modePath(VideoRecordingWorkingMode, PlaybackWorkingMode)
+	require.False(t, ok)
+
+	_, ok = modePath(VideoRecordWorkingMode, ExitStandbyWorkingMode)
+	require.False(t, ok)
+}
+
+func TestEnsureMode(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	mode, err := cli.QueryWorkingMode(ctx)
+	require.NoError(t, err)
+	require.Equal(t, VideoRecordWorkingMode, mode)
+
+	require.NoError(t, cli.SetWorkingMode(ctx, StandbyWorkingMode))
+	require.NoError(t, cli.EnsureMode(ctx, PlaybackWorkingMode))
+	require.Equal(t, zcamtest.ModePlayback, srv.Camera.Mode())
+
+	require.NoError(t, cli.EnsureMode(ctx, VideoRecordTimeLapseWorkingMode))
+	require.Equal(t, zcamtest.ModeTimeLapse, srv.Camera.Mode())
+
+	require.NoError(t, cli.StartVideoRecord(ctx))
+	mode, err = cli.QueryWorkingMode(ctx)
+	require.NoError(t, err)
+	require.Equal(t, VideoRecordingTimeLapseWorkingMode, mode)
+	require.ErrorIs(t, cli.EnsureMode(ctx, PlaybackWorkingMode), ErrInvalidTransition)
+}
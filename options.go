@@ -0,0 +1,156 @@
+package zcam
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Option configures a Camera, to be used with NewCamera.
+type Option func(*config)
+
+type config struct {
+	scheme      string
+	port        int
+	basePath    string
+	timeout     time.Duration
+	transport   http.RoundTripper
+	userAgent   string
+	username    string
+	password    string
+	basicAuth   *url.Userinfo
+	retry       *RetryPolicy
+	validate    bool
+	concurrency int
+	cache       *CachePolicy
+}
+
+// WithPort sets the port of the camera HTTP server, overriding the one
+// included in the host, if any.
+func WithPort(port int) Option {
+	return func(c *config) {
+		c.port = port
+	}
+}
+
+// WithScheme sets the URL scheme, by default "http".
+func WithScheme(scheme string) Option {
+	return func(c *config) {
+		c.scheme = scheme
+	}
+}
+
+// WithBasePath sets a path prefix for every endpoint, useful when the camera
+// is behind a reverse proxy.
+func WithBasePath(path string) Option {
+	return func(c *config) {
+		c.basePath = path
+	}
+}
+
+// WithTimeout sets the time limit of every request, including reading the
+// response body. Zero means no timeout.
+func WithTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.timeout = d
+	}
+}
+
+// WithTransport sets the http.RoundTripper used to perform the requests.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *config) {
+		c.transport = rt
+	}
+}
+
+// WithUserAgent sets the User-Agent header of every request.
+func WithUserAgent(ua string) Option {
+	return func(c *config) {
+		c.userAgent = ua
+	}
+}
+
+// WithCredentials sets the username and password used to log in on the
+// firmwares requiring it, they are only sent in the login form.
+func WithCredentials(username, password string) Option {
+	return func(c *config) {
+		c.username = username
+		c.password = password
+	}
+}
+
+// WithBasicAuth sets the username and password sent as HTTP basic
+// authentication in every request, for the cameras behind an authenticating
+// proxy. The basic authentication is sent in cleartext unless the scheme is
+// "https", see WithScheme.
+func WithBasicAuth(username, password string) Option {
+	return func(c *config) {
+		c.basicAuth = url.UserPassword(username, password)
+	}
+}
+
+// WithRetryPolicy sets the RetryPolicy of the camera.
+func WithRetryPolicy(p *RetryPolicy) Option {
+	return func(c *config) {
+		c.retry = p
+	}
+}
+
+// WithValidation enables the client-side validation of the values sent by
+// SetSetting, see ValidateSetting.
+func WithValidation() Option {
+	return func(c *config) {
+		c.validate = true
+	}
+}
+
+// WithConcurrency sets the maximum number of concurrent requests made by
+// GetSettings, the camera HTTP server doesn't cope well with many of them.
+func WithConcurrency(n int) Option {
+	return func(c *config) {
+		c.concurrency = n
+	}
+}
+
+// WithCache sets the CachePolicy of the values read by GetSetting.
+func WithCache(p *CachePolicy) Option {
+	return func(c *config) {
+		c.cache = p
+	}
+}
+
+// buildBaseURL returns the base URL for the given host, the host may be a
+// hostname, an IPv4 or an IPv6 literal, with or without port.
+func buildBaseURL(host string, cfg *config) string {
+	hostname, port, err := net.SplitHostPort(host)
+	if err != nil {
+		hostname, port = strings.Trim(host, "[]"), ""
+	}
+
+	if cfg.port != 0 {
+		port = strconv.Itoa(cfg.port)
+	}
+
+	// the IPv6 zone needs to be escaped in the URL
+	hostname = strings.Replace(hostname, "%", "%25", 1)
+
+	switch {
+	case port != "":
+		host = net.JoinHostPort(hostname, port)
+	case strings.Contains(hostname, ":"):
+		host = "[" + hostname + "]"
+	default:
+		host = hostname
+	}
+
+	path := strings.TrimRight(cfg.basePath, "/")
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	return fmt.Sprintf("%s://%s%s", cfg.scheme, host, path)
+}
@@ -0,0 +1,84 @@
+package zcam
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCameraBaseURL(t *testing.T) {
+	for _, tc := range []struct {
+		host     string
+		opts     []Option
+		expected string
+	}{
+		{"192.168.9.81", nil, "http://192.168.9.81"},
+		{"192.168.9.81:8080", nil, "http://192.168.9.81:8080"},
+		{"192.168.9.81:8080", []Option{WithPort(80)}, "http://192.168.9.81:80"},
+		{"camera.local", []Option{WithPort(8080)}, "http://camera.local:8080"},
+		{"fe80::1", nil, "http://[fe80::1]"},
+		{"[fe80::1]", nil, "http://[fe80::1]"},
+		{"fe80::1", []Option{WithPort(80)}, "http://[fe80::1]:80"},
+		{"[fe80::1]:80", nil, "http://[fe80::1]:80"},
+		{"fe80::1%eth0", nil, "http://[fe80::1%25eth0]"},
+		{"proxy.local", []Option{WithScheme("https"), WithBasePath("/cam1/")}, "https://proxy.local/cam1"},
+		{"proxy.local", []Option{WithBasePath("cam1")}, "http://proxy.local/cam1"},
+	} {
+		cli := NewCamera(tc.host, tc.opts...)
+		require.Equal(t, tc.expected, cli.baseURL, tc.host)
+	}
+}
+
+func TestNewCameraOptions(t *testing.T) {
+	var header http.Header
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header, path = r.Header, r.URL.Path
+		w.Write([]byte(`{"code": 0, "desc": "", "msg": ""}`))
+	}))
+	defer server.Close()
+
+	var used bool
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		used = true
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	cli := NewCamera(strings.TrimPrefix(server.URL, "http://"),
+		WithBasePath("/proxy"),
+		WithTimeout(time.Second),
+		WithTransport(transport),
+		WithUserAgent("zcam-test"),
+		WithBasicAuth("admin", "secret"),
+		WithRetryPolicy(DefaultRetryPolicy()),
+	)
+
+	require.NoError(t, cli.RebootSystem(context.Background()))
+	require.True(t, used)
+	require.Equal(t, "/proxy/ctrl/reboot", path)
+	require.Equal(t, "zcam-test", header.Get("User-Agent"))
+	require.Equal(t, "Basic YWRtaW46c2VjcmV0", header.Get("Authorization"))
+	require.Equal(t, time.Second, cli.Client.Timeout)
+	require.NotNil(t, cli.Retry)
+}
+
+func TestNewCameraTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	cli := NewCamera(strings.TrimPrefix(server.URL, "http://"), WithTimeout(10*time.Millisecond))
+	require.Error(t, cli.RebootSystem(context.Background()))
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
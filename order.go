@@ -0,0 +1,234 @@
+package zcam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+)
+
+// ErrSettingMismatch is returned by SetSettings when the value read back from
+// the camera differs from the one set.
+var ErrSettingMismatch = errors.New("setting value mismatch")
+
+// settingsOrder is the order in which the settings are applied, changing a
+// setting may reset or reject the values of the ones after it. The settings
+// not listed are applied at the end, in alphabetical order.
+var settingsOrder = []settings.Setting{
+	// the video system and the flicker limit the available frame rates
+	settings.VideoSystemSetting,
+	settings.FlickerSetting,
+
+	// format
+	settings.RecordFileFormatSetting,
+	settings.MovFmtSetting,
+	settings.ResolutionSetting,
+
+	// frame rate
+	settings.ProjectFPSSetting,
+	settings.MovVFRSetting,
+	settings.RecFPSSetting,
+
+	// encoder
+	settings.VideoEncoderSetting,
+	settings.BitrateLevelSetting,
+	settings.ComposeModeSetting,
+	settings.RecProxyFileSetting,
+	settings.SplitDurationSetting,
+	settings.VideoTLIntervalSetting,
+
+	// exposure, the modes and limits before the values
+	settings.MeterModeSetting,
+	settings.ShtOperationSetting,
+	settings.MaxISOSetting,
+	settings.DualISOSetting,
+	settings.ISOSetting,
+	settings.IrisSetting,
+	settings.MaxExpShutterAngleSetting,
+	settings.ShutterAngleSetting,
+	settings.MaxExpShutterTimeSetting,
+	settings.ShutterTimeSetting,
+	settings.EVChoiceSetting,
+	settings.AEFreezeSetting,
+
+	// white balance
+	settings.WBSetting,
+	settings.WBPrioritySetting,
+	settings.MWBSetting,
+	settings.TintSetting,
+	settings.MWBRSetting,
+	settings.MWBGSetting,
+	settings.MWBBSetting,
+
+	// focus
+	settings.AFModeSetting,
+	settings.CAFSetting,
+	settings.CAFSensSetting,
+	settings.FocusSetting,
+}
+
+// settingDependents are the settings that the camera may change when the
+// key is changed.
+var settingDependents = map[settings.Setting][]settings.Setting{
+	settings.VideoSystemSetting: {
+		settings.MovFmtSetting, settings.ProjectFPSSetting, settings.RecFPSSetting,
+	},
+	settings.MovFmtSetting: {
+		settings.ResolutionSetting, settings.ProjectFPSSetting, settings.MovVFRSetting, settings.RecFPSSetting,
+	},
+	settings.ResolutionSetting: {
+		settings.ProjectFPSSetting, settings.MovVFRSetting, settings.RecFPSSetting,
+	},
+	settings.ProjectFPSSetting: {
+		settings.MovVFRSetting, settings.RecFPSSetting,
+	},
+	settings.RecordFileFormatSetting: {
+		settings.VideoEncoderSetting,
+	},
+}
+
+// withDependents returns the key along with its dependents, including the
+// dependents of the dependents.
+func withDependents(key settings.Setting) []settings.Setting {
+	keys := []settings.Setting{key}
+	seen := map[settings.Setting]bool{key: true}
+	for i := 0; i < len(keys); i++ {
+		for _, dep := range settingDependents[keys[i]] {
+			if !seen[dep] {
+				seen[dep] = true
+				keys = append(keys, dep)
+			}
+		}
+	}
+
+	return keys
+}
+
+var settingsRank = func() map[settings.Setting]int {
+	rank := make(map[settings.Setting]int, len(settingsOrder))
+	for i, key := range settingsOrder {
+		rank[key] = i
+	}
+
+	return rank
+}()
+
+// sortSettings sorts the keys in the order they should be applied.
+func sortSettings(keys []settings.Setting) {
+	sort.SliceStable(keys, func(i, j int) bool {
+		return lessSetting(keys[i], keys[j])
+	})
+}
+
+// lessSetting reports whether a should be applied before b.
+func lessSetting(a, b settings.Setting) bool {
+	ra, ok := settingsRank[a]
+	if !ok {
+		ra = len(settingsOrder)
+	}
+
+	rb, ok := settingsRank[b]
+	if !ok {
+		rb = len(settingsOrder)
+	}
+
+	if ra != rb {
+		return ra < rb
+	}
+
+	return a < b
+}
+
+// setAndVerify changes the setting and reads it back, returning an error
+// matching ErrSettingMismatch if the camera didn't apply the value.
+func (c *Camera) setAndVerify(ctx context.Context, key settings.Setting, value any) error {
+	if err := c.SetSetting(ctx, key, value); err != nil {
+		return err
+	}
+
+	expected, err := convertToString(value)
+	if err != nil {
+		return err
+	}
+
+	v, err := c.GetSetting(ctx, key)
+	if err != nil {
+		return fmt.Errorf("unable to verify: %w", err)
+	}
+
+	current, err := v.StringValue()
+	if err != nil {
+		return fmt.Errorf("unable to verify: %w", err)
+	}
+
+	if current != expected {
+		return fmt.Errorf("%w: %s is %q, expected %q", ErrSettingMismatch, key, current, expected)
+	}
+
+	return nil
+}
+
+// savePrevious stores the current value of the key, and of the settings that
+// may change along with it, to allow restoring them.
+func (c *Camera) savePrevious(ctx context.Context, previous map[settings.Setting]string, key settings.Setting) error {
+	for i, k := range append([]settings.Setting{key}, settingDependents[key]...) {
+		if _, ok := previous[k]; ok {
+			continue
+		}
+
+		v, err := c.GetSetting(ctx, k)
+		if err != nil {
+			// the dependents may not be supported by every model
+			if i > 0 && errors.Is(err, ErrNotSupported) {
+				continue
+			}
+
+			return fmt.Errorf("error reading %s: %w", k, err)
+		}
+
+		s, err := v.StringValue()
+		if err != nil || v.ReadOnly {
+			continue
+		}
+
+		previous[k] = s
+	}
+
+	return nil
+}
+
+// rollback restores the previous values that changed, in the same order they
+// are applied, so the dependents are restored after the settings resetting
+// them. The rollback is performed even if the context is canceled.
+func (c *Camera) rollback(ctx context.Context, previous map[settings.Setting]string, cause error) error {
+	ctx = context.WithoutCancel(ctx)
+
+	keys := make([]settings.Setting, 0, len(previous))
+	for k := range previous {
+		keys = append(keys, k)
+	}
+
+	sortSettings(keys)
+
+	var errs []error
+	for _, k := range keys {
+		v, err := c.GetSetting(ctx, k)
+		if err == nil {
+			if current, err := v.StringValue(); err == nil && current == previous[k] {
+				continue
+			}
+		}
+
+		if err := c.SetSetting(ctx, k, previous[k]); err != nil {
+			errs = append(errs, fmt.Errorf("error restoring %s: %w", k, err))
+		}
+	}
+
+	if len(errs) != 0 {
+		return fmt.Errorf("%w, rollback failed: %w", cause, errors.Join(errs...))
+	}
+
+	return cause
+}
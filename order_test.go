@@ -0,0 +1,98 @@
+package zcam
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+	"github.com/mcuadros/go-zcam-e2/zcamtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortSettings(t *testing.T) {
+	keys := []settings.Setting{
+		settings.LEDSetting,
+		settings.ISOSetting,
+		settings.MovVFRSetting,
+		settings.ContrastSetting,
+		settings.ResolutionSetting,
+		settings.FlickerSetting,
+	}
+
+	sortSettings(keys)
+	require.Equal(t, []settings.Setting{
+		settings.FlickerSetting,
+		settings.ResolutionSetting,
+		settings.MovVFRSetting,
+		settings.ISOSetting,
+		settings.ContrastSetting,
+		settings.LEDSetting,
+	}, keys)
+}
+
+func TestSetSettingsOrder(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	cli := NewCamera(srv.Listener.Addr().String())
+
+	// the emulator resets movvfr when the resolution changes
+	err := cli.SetSettings(context.Background(), map[settings.Setting]any{
+		settings.MovVFRSetting:     120,
+		settings.ResolutionSetting: "1920x1080",
+		settings.FlickerSetting:    "60Hz",
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, "120", srv.Camera.Value(settings.MovVFRSetting))
+	require.Equal(t, "1920x1080", srv.Camera.Value(settings.ResolutionSetting))
+	require.Equal(t, "60Hz", srv.Camera.Value(settings.FlickerSetting))
+}
+
+func TestSetSettingsRollback(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	srv.Camera.SetValue(settings.MovVFRSetting, "60")
+	cli := NewCamera(srv.Listener.Addr().String())
+
+	err := cli.SetSettings(context.Background(), map[settings.Setting]any{
+		settings.ResolutionSetting: "1920x1080",
+		settings.FlickerSetting:    "60Hz",
+		settings.ISOSetting:        "42",
+	})
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	require.ErrorContains(t, err, "error setting iso")
+	require.Equal(t, "4K", srv.Camera.Value(settings.ResolutionSetting))
+	require.Equal(t, "50Hz", srv.Camera.Value(settings.FlickerSetting))
+	require.Equal(t, "60", srv.Camera.Value(settings.MovVFRSetting))
+}
+
+func TestSetSettingsVerify(t *testing.T) {
+	srv := zcamtest.NewUnstartedServer()
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the flicker change is acknowledged but ignored
+		if strings.HasPrefix(r.RequestURI, "/ctrl/set?flicker=") {
+			w.Write([]byte(`{"code":0}`))
+			return
+		}
+
+		srv.Camera.ServeHTTP(w, r)
+	})
+
+	srv.Start()
+	defer srv.Close()
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	err := cli.SetSettings(context.Background(), map[settings.Setting]any{
+		settings.FlickerSetting:  "60Hz",
+		settings.ContrastSetting: 60,
+	})
+
+	require.ErrorIs(t, err, ErrSettingMismatch)
+	require.Equal(t, 50, srv.Camera.Value(settings.ContrastSetting))
+}
@@ -0,0 +1,259 @@
+package zcam
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+	"gopkg.in/yaml.v3"
+)
+
+// Reasons of the skipped settings in a Profile or ApplyReport.
+const (
+	SkippedReadOnly     = "read-only"
+	SkippedNotSupported = "not supported"
+)
+
+// profileExcluded are the settings never included in a profile, being
+// commands instead of state, such as driving the lens, or identifying the
+// camera body.
+var profileExcluded = map[settings.Setting]bool{
+	settings.MFDriveSetting:  true,
+	settings.LensZoomSetting: true,
+	settings.SSIDSetting:     true,
+}
+
+// Profile is a camera configuration, created by Snapshot and restored with
+// ApplyProfile. It can be serialized as JSON or YAML.
+type Profile struct {
+	// Model and Firmware of the camera the profile was taken from.
+	Model     string    `json:"model,omitempty" yaml:"model,omitempty"`
+	Firmware  string    `json:"firmware,omitempty" yaml:"firmware,omitempty"`
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+	// Settings contains the values by setting, formatted as sent to the
+	// camera.
+	Settings map[settings.Setting]string `json:"settings" yaml:"settings"`
+	// Skipped are the settings not included, with the reason.
+	Skipped map[settings.Setting]string `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+}
+
+// Snapshot reads every known setting into a Profile. The read-only settings
+// and the ones not supported by the camera are listed in Profile.Skipped.
+func (c *Camera) Snapshot(ctx context.Context) (*Profile, error) {
+	info, err := c.GetCameraInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Profile{
+		Model:     info.Model,
+		Firmware:  info.Sw,
+		CreatedAt: time.Now().UTC(),
+		Settings:  make(map[settings.Setting]string),
+		Skipped:   make(map[settings.Setting]string),
+	}
+
+	for _, key := range settings.All {
+		if profileExcluded[key] {
+			continue
+		}
+
+		v, reason, err := c.readProfileSetting(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		if reason != "" {
+			p.Skipped[key] = reason
+			continue
+		}
+
+		p.Settings[key] = v
+	}
+
+	return p, nil
+}
+
+// readProfileSetting returns the current value of the setting, or the reason
+// if it can't be part of a profile. Only the settings confirmed as not
+// supported by GetSetting are skipped, any other failure is returned.
+func (c *Camera) readProfileSetting(ctx context.Context, key settings.Setting) (value, reason string, err error) {
+	v, err := c.GetSetting(ctx, key)
+	if errors.Is(err, ErrNotSupported) {
+		return "", SkippedNotSupported, nil
+	}
+
+	if err != nil {
+		return "", "", fmt.Errorf("error reading %s: %w", key, err)
+	}
+
+	if v.ReadOnly {
+		return "", SkippedReadOnly, nil
+	}
+
+	value, err = v.StringValue()
+	if err != nil {
+		return "", "", err
+	}
+
+	return value, "", nil
+}
+
+// SettingChange is a setting changed by ApplyProfile.
+type SettingChange struct {
+	Key  settings.Setting `json:"key" yaml:"key"`
+	From string           `json:"from" yaml:"from"`
+	To   string           `json:"to" yaml:"to"`
+}
+
+// ApplyReport describes the result of ApplyProfile.
+type ApplyReport struct {
+	// Changed are the settings changed, in the order they were applied.
+	Changed []SettingChange `json:"changed" yaml:"changed"`
+	// Skipped are the settings of the profile not applied, with the reason.
+	Skipped map[settings.Setting]string `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+}
+
+// String returns a human readable diff of the changes.
+func (r *ApplyReport) String() string {
+	var b strings.Builder
+	for _, c := range r.Changed {
+		fmt.Fprintf(&b, "%s: %q -> %q\n", c.Key, c.From, c.To)
+	}
+
+	keys := make([]string, 0, len(r.Skipped))
+	for k := range r.Skipped {
+		keys = append(keys, string(k))
+	}
+
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s: skipped, %s\n", k, r.Skipped[settings.Setting(k)])
+	}
+
+	return b.String()
+}
+
+// ApplyProfile restores the settings of a profile, only the ones differing
+// from the current values are changed. They are applied as SetSettings does,
+// in dependency order and rolling back all of them if any fails. The
+// read-only settings and the ones not supported are skipped.
+func (c *Camera) ApplyProfile(ctx context.Context, p *Profile) (*ApplyReport, error) {
+	r := &ApplyReport{Skipped: make(map[settings.Setting]string)}
+	values := make(map[settings.Setting]any)
+	for key, value := range p.Settings {
+		if profileExcluded[key] {
+			continue
+		}
+
+		current, reason, err := c.readProfileSetting(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		if reason != "" {
+			r.Skipped[key] = reason
+			continue
+		}
+
+		if current != value {
+			values[key] = value
+			r.Changed = append(r.Changed, SettingChange{Key: key, From: current, To: value})
+		}
+	}
+
+	// the dependents of the changed settings are applied even if they match,
+	// since the camera may reset them.
+	for _, change := range r.Changed {
+		for _, d := range settingDependents[change.Key] {
+			if _, ok := values[d]; ok || r.Skipped[d] != "" {
+				continue
+			}
+
+			if value, ok := p.Settings[d]; ok {
+				values[d] = value
+			}
+		}
+	}
+
+	sort.Slice(r.Changed, func(i, j int) bool {
+		return lessSetting(r.Changed[i].Key, r.Changed[j].Key)
+	})
+
+	if err := c.SetSettings(ctx, values); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// ReadProfile decodes a profile from YAML, being JSON a subset of YAML both
+// formats are accepted.
+func ReadProfile(r io.Reader) (*Profile, error) {
+	p := &Profile{}
+	if err := yaml.NewDecoder(r).Decode(p); err != nil {
+		return nil, fmt.Errorf("error decoding profile: %w", err)
+	}
+
+	return p, nil
+}
+
+// WriteJSON encodes the profile as JSON.
+func (p *Profile) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// WriteYAML encodes the profile as YAML.
+func (p *Profile) WriteYAML(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(p); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// LoadProfile reads a profile from a JSON or YAML file.
+func LoadProfile(filename string) (*Profile, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+	return ReadProfile(f)
+}
+
+// Save writes the profile to a file, as YAML if the extension is .yaml or
+// .yml, otherwise as JSON.
+func (p *Profile) Save(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		err = p.WriteYAML(f)
+	default:
+		err = p.WriteJSON(f)
+	}
+
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,119 @@
+package zcam
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+	"github.com/mcuadros/go-zcam-e2/zcamtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	p, err := cli.Snapshot(context.Background())
+	require.NoError(t, err)
+
+	require.NotEmpty(t, p.Model)
+	require.Equal(t, "Flexible Zone", p.Settings[settings.AFModeSetting])
+	require.Equal(t, "5600", p.Settings[settings.MWBSetting])
+	require.Equal(t, SkippedReadOnly, p.Skipped[settings.BatterySetting])
+	require.Equal(t, SkippedNotSupported, p.Skipped[settings.VignetteSetting])
+	require.NotContains(t, p.Settings, settings.BatterySetting)
+	require.NotContains(t, p.Settings, settings.MFDriveSetting)
+	require.NotContains(t, p.Settings, settings.SSIDSetting)
+}
+
+func TestApplyProfile(t *testing.T) {
+	src := zcamtest.NewServer()
+	defer src.Close()
+
+	src.Camera.SetValue(settings.ResolutionSetting, "1920x1080")
+	src.Camera.SetValue(settings.MovVFRSetting, "120")
+	src.Camera.SetValue(settings.ContrastSetting, 70)
+
+	ctx := context.Background()
+	p, err := NewCamera(src.Listener.Addr().String()).Snapshot(ctx)
+	require.NoError(t, err)
+
+	p.Settings[settings.BatterySetting] = "50"
+
+	dst := zcamtest.NewServer()
+	defer dst.Close()
+
+	dst.Camera.SetValue(settings.MovVFRSetting, "120")
+	dst.Camera.RemoveSetting(settings.LEDSetting)
+
+	r, err := NewCamera(dst.Listener.Addr().String()).ApplyProfile(ctx, p)
+	require.NoError(t, err)
+	require.Equal(t, []SettingChange{
+		{Key: settings.ResolutionSetting, From: "4K", To: "1920x1080"},
+		{Key: settings.ContrastSetting, From: "50", To: "70"},
+	}, r.Changed)
+	require.Equal(t, map[settings.Setting]string{
+		settings.BatterySetting: SkippedReadOnly,
+		settings.LEDSetting:     SkippedNotSupported,
+	}, r.Skipped)
+
+	require.Equal(t, "1920x1080", dst.Camera.Value(settings.ResolutionSetting))
+	require.Equal(t, "120", dst.Camera.Value(settings.MovVFRSetting))
+	require.Equal(t, 70, dst.Camera.Value(settings.ContrastSetting))
+	require.Contains(t, r.String(), "contrast: \"50\" -> \"70\"\n")
+}
+
+func TestProfileRejectedOnce(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	ctx := context.Background()
+
+	// as the camera does, the failure has a non-zero code and no description
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/ctrl/get?k=iso", Code: -1, Count: 1})
+	p, err := cli.Snapshot(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "Auto", p.Settings[settings.ISOSetting])
+	require.NotContains(t, p.Skipped, settings.ISOSetting)
+
+	p = &Profile{Settings: map[settings.Setting]string{settings.ISOSetting: "800"}}
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/ctrl/get?k=iso", Code: -1, Count: 1})
+	r, err := cli.ApplyProfile(ctx, p)
+	require.NoError(t, err)
+	require.Empty(t, r.Skipped)
+	require.Equal(t, "800", srv.Camera.Value(settings.ISOSetting))
+
+	p.Settings[settings.ISOSetting] = "1600"
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/ctrl/get?k=iso", StatusCode: http.StatusInternalServerError})
+	_, err = cli.ApplyProfile(ctx, p)
+	require.ErrorContains(t, err, "error reading iso")
+	require.Equal(t, "800", srv.Camera.Value(settings.ISOSetting))
+}
+
+func TestProfileEncoding(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	p, err := NewCamera(srv.Listener.Addr().String()).Snapshot(context.Background())
+	require.NoError(t, err)
+
+	for _, name := range []string{"profile.json", "profile.yaml"} {
+		filename := filepath.Join(t.TempDir(), name)
+		require.NoError(t, p.Save(filename))
+
+		loaded, err := LoadProfile(filename)
+		require.NoError(t, err)
+		require.Equal(t, p.Settings, loaded.Settings)
+		require.Equal(t, p.Skipped, loaded.Skipped)
+		require.True(t, p.CreatedAt.Equal(loaded.CreatedAt))
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, p.WriteYAML(&buf))
+	require.Contains(t, buf.String(), "af_mode: Flexible Zone\n")
+}
@@ -0,0 +1,285 @@
+package zcam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+)
+
+var (
+	// ErrRecordingStopped is returned by VideoRecord when the camera stopped
+	// recording before the requested duration.
+	ErrRecordingStopped = errors.New("recording stopped unexpectedly")
+	// ErrCardFull is returned along with ErrRecordingStopped when the card
+	// has no space left.
+	ErrCardFull = errors.New("card full")
+	// ErrOverheated is returned along with ErrRecordingStopped when the
+	// camera reports a temperature over OverheatTemperature.
+	ErrOverheated = errors.New("camera overheated")
+)
+
+// OverheatTemperature is the temperature in celsius from which the camera is
+// considered to have stopped the recording to protect itself.
+const OverheatTemperature = 85
+
+const (
+	// recordPollInterval is the wait between the status queries while
+	// recording, or waiting for a recording to start or stop.
+	recordPollInterval = 250 * time.Millisecond
+	// recordConfirmTimeout limits the wait for a recording to start or stop.
+	recordConfirmTimeout = 10 * time.Second
+	// recordStatusAttempts is the number of consecutive status queries that
+	// may fail while recording, before giving up.
+	recordStatusAttempts = 3
+)
+
+// RecordingState is the state of the video recording.
+type RecordingState string
+
+// States of the video recording.
+const (
+	RecordingIdle     RecordingState = "idle"
+	RecordingActive   RecordingState = "recording"
+	RecordingStopping RecordingState = "stopping"
+)
+
+// RecordingStatus is the status of the video recording, returned by
+// QueryRecordingStatus.
+type RecordingStatus struct {
+	State RecordingState
+	// Mode is the working mode reported by the camera.
+	Mode WorkingMode
+	// Elapsed is the duration of the current recording, zero if idle.
+	Elapsed time.Duration
+}
+
+// QueryRecordingStatus queries whether the camera is recording, based on the
+// working mode, and for how long.
+func (c *Camera) QueryRecordingStatus(ctx context.Context) (*RecordingStatus, error) {
+	mode, err := c.QueryWorkingMode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &RecordingStatus{State: RecordingIdle, Mode: mode}
+	switch mode {
+	case VideoRecordingWorkingMode, VideoRecordingTimeLapseWorkingMode:
+		s.State = RecordingActive
+	case VideoRecordStoppingWorkingMode:
+		s.State = RecordingStopping
+	default:
+		return s, nil
+	}
+
+	v, err := c.GetSetting(ctx, settings.RecDurationSetting)
+	if err != nil {
+		return nil, fmt.Errorf("unable to recover %s setting: %w", settings.RecDurationSetting, err)
+	}
+
+	secs, err := v.Int()
+	if err != nil {
+		return nil, err
+	}
+
+	s.Elapsed = time.Duration(secs) * time.Second
+	return s, nil
+}
+
+// StartVideoRecordAndWait starts a video recording, as StartVideoRecord
+// does, and waits until the camera reports it's recording.
+func (c *Camera) StartVideoRecordAndWait(ctx context.Context) error {
+	if err := c.StartVideoRecord(ctx); err != nil {
+		return err
+	}
+
+	return c.waitRecordingState(ctx, RecordingActive)
+}
+
+// StopVideoRecordAndWait stops the video recording, as StopVideoRecord does,
+// and waits until the camera has written the files.
+func (c *Camera) StopVideoRecordAndWait(ctx context.Context) error {
+	if err := c.StopVideoRecord(ctx); err != nil {
+		return err
+	}
+
+	return c.waitRecordingState(ctx, RecordingIdle)
+}
+
+func (c *Camera) waitRecordingState(ctx context.Context, state RecordingState) error {
+	ctx, cancel := context.WithTimeout(ctx, recordConfirmTimeout)
+	defer cancel()
+
+	for {
+		s, err := c.QueryRecordingStatus(ctx)
+		if err != nil {
+			return err
+		}
+
+		if s.State == state {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for recording to be %s, camera is %s: %w", state, s.State, ctx.Err())
+		case <-time.After(recordPollInterval):
+		}
+	}
+}
+
+// Recording is the result of Record, holding every file created by the
+// recording.
+type Recording struct {
+	// Segments are the clips of the recording, sorted by name, being the
+	// order they were recorded. The recordings are split in several clips
+	// when settings.SplitDurationSetting is set.
+	Segments []*Clip
+}
+
+// Files returns the files of every segment, including the proxies.
+func (r *Recording) Files() []*File {
+	var files []*File
+	for _, s := range r.Segments {
+		files = append(files, s.Files()...)
+	}
+
+	return files
+}
+
+// Last returns the file of the last segment, nil if there is none.
+func (r *Recording) Last() *File {
+	if len(r.Segments) == 0 {
+		return nil
+	}
+
+	return r.Segments[len(r.Segments)-1].Main
+}
+
+// newRecording groups the video files in segments, the stills are ignored.
+func newRecording(files []*File) *Recording {
+	r := &Recording{}
+	for _, c := range groupClips(files) {
+		// a proxy without its main file is not a segment
+		if c.Main != nil {
+			r.Segments = append(r.Segments, &Clip{Main: c.Main, Proxy: c.Proxy})
+		}
+	}
+
+	return r
+}
+
+// Record records a video of the given duration, as VideoRecord does, and
+// returns every file created, finding them by comparing the files in the
+// card before and after the recording.
+func (c *Camera) Record(ctx context.Context, d time.Duration) (*Recording, error) {
+	before, err := c.ListAllFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.recordFor(ctx, d); err != nil {
+		return nil, err
+	}
+
+	after, err := c.ListAllFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]bool, len(before))
+	for _, f := range before {
+		existing[f.folder+"/"+f.file] = true
+	}
+
+	var created []*File
+	for _, f := range after {
+		if !existing[f.folder+"/"+f.file] {
+			created = append(created, f)
+		}
+	}
+
+	return newRecording(created), nil
+}
+
+// recordFor records a video of the given duration, checking the status while
+// recording.
+func (c *Camera) recordFor(ctx context.Context, d time.Duration) error {
+	if err := c.StartVideoRecord(ctx); err != nil {
+		return fmt.Errorf("error starting video: %w", err)
+	}
+
+	// the start command was accepted, so the camera may be recording even if
+	// it can't be confirmed.
+	if err := c.waitRecordingState(ctx, RecordingActive); err != nil {
+		return c.abortRecording(ctx, fmt.Errorf("error starting video: %w", err))
+	}
+
+	start := time.Now()
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	ticker := time.NewTicker(recordPollInterval)
+	defer ticker.Stop()
+
+	var failures int
+	for done := false; !done; {
+		select {
+		case <-ctx.Done():
+			return c.abortRecording(ctx, fmt.Errorf("recording canceled: %w", ctx.Err()))
+		case <-ticker.C:
+			s, err := c.QueryRecordingStatus(ctx)
+			if err != nil {
+				failures++
+				if failures < recordStatusAttempts {
+					continue
+				}
+
+				return c.abortRecording(ctx, fmt.Errorf("error querying recording status: %w", err))
+			}
+
+			failures = 0
+			if s.State == RecordingIdle {
+				return c.recordingStopCause(ctx, time.Since(start))
+			}
+		case <-timer.C:
+			done = true
+		}
+	}
+
+	if err := c.StopVideoRecordAndWait(ctx); err != nil {
+		return fmt.Errorf("error stopping video: %w", err)
+	}
+
+	return nil
+}
+
+// abortRecording stops the recording after a failure, even if the context is
+// canceled, returning the cause along with the error stopping it, if any.
+func (c *Camera) abortRecording(ctx context.Context, cause error) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordConfirmTimeout)
+	defer cancel()
+
+	if err := c.StopVideoRecord(ctx); err != nil {
+		return fmt.Errorf("%w, error stopping video: %w", cause, err)
+	}
+
+	return cause
+}
+
+// recordingStopCause returns an error matching ErrRecordingStopped, and
+// ErrCardFull or ErrOverheated if the cause is detected.
+func (c *Camera) recordingStopCause(ctx context.Context, elapsed time.Duration) error {
+	err := fmt.Errorf("%w after %s", ErrRecordingStopped, elapsed.Round(time.Second))
+	if remain, rerr := c.QueryRemainingRecordingTime(ctx); rerr == nil && remain <= 0 {
+		return fmt.Errorf("%w: %w", err, ErrCardFull)
+	}
+
+	if t, terr := c.QueryTemperature(ctx); terr == nil && t >= OverheatTemperature {
+		return fmt.Errorf("%w: %w", err, ErrOverheated)
+	}
+
+	return err
+}
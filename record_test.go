@@ -0,0 +1,164 @@
+package zcam
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+	"github.com/mcuadros/go-zcam-e2/zcamtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryRecordingStatus(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	srv.Camera.Now = func() time.Time { return now }
+	srv.Camera.SetStopDelay(300 * time.Millisecond)
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	ctx := context.Background()
+
+	s, err := cli.QueryRecordingStatus(ctx)
+	require.NoError(t, err)
+	require.Equal(t, &RecordingStatus{State: RecordingIdle, Mode: VideoRecordWorkingMode}, s)
+
+	require.NoError(t, cli.StartVideoRecordAndWait(ctx))
+	now = now.Add(12 * time.Second)
+
+	s, err = cli.QueryRecordingStatus(ctx)
+	require.NoError(t, err)
+	require.Equal(t, RecordingActive, s.State)
+	require.Equal(t, 12*time.Second, s.Elapsed)
+
+	require.NoError(t, cli.StopVideoRecord(ctx))
+	s, err = cli.QueryRecordingStatus(ctx)
+	require.NoError(t, err)
+	require.Equal(t, RecordingStopping, s.State)
+
+	require.NoError(t, cli.waitRecordingState(ctx, RecordingIdle))
+	require.Len(t, srv.Camera.Files(zcamtest.DefaultFolder), 1)
+}
+
+func TestVideoRecordStopped(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	time.AfterFunc(100*time.Millisecond, func() {
+		srv.Camera.SetCardFreeSpace(0)
+		srv.Camera.StopRecording()
+	})
+
+	_, err := cli.VideoRecord(context.Background(), 5*time.Second)
+	require.ErrorIs(t, err, ErrRecordingStopped)
+	require.ErrorIs(t, err, ErrCardFull)
+
+	srv.Camera.SetCardFreeSpace(100000)
+	srv.Camera.SetTemperature(90)
+	time.AfterFunc(100*time.Millisecond, srv.Camera.StopRecording)
+
+	_, err = cli.VideoRecord(context.Background(), 5*time.Second)
+	require.ErrorIs(t, err, ErrRecordingStopped)
+	require.ErrorIs(t, err, ErrOverheated)
+}
+
+func TestVideoRecordCanceled(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := cli.VideoRecord(ctx, 5*time.Second)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.False(t, srv.Camera.IsRecording())
+}
+
+func TestVideoRecordStartUnconfirmed(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/ctrl/mode", StatusCode: http.StatusInternalServerError})
+
+	_, err := cli.VideoRecord(context.Background(), 5*time.Second)
+	require.ErrorContains(t, err, "error starting video")
+	require.False(t, srv.Camera.IsRecording())
+
+	srv.Faults.Reset()
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/ctrl/mode", Latency: time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = cli.VideoRecord(ctx, 5*time.Second)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.False(t, srv.Camera.IsRecording())
+}
+
+func TestVideoRecordStatusFailure(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	time.AfterFunc(100*time.Millisecond, func() {
+		srv.Faults.Add(zcamtest.Fault{Endpoint: "/ctrl/mode", StatusCode: 500})
+	})
+
+	_, err := cli.VideoRecord(context.Background(), 5*time.Second)
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, 500, apiErr.HTTPStatus)
+	require.False(t, srv.Camera.IsRecording())
+}
+
+func TestRecord(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	srv.Camera.AddFile(zcamtest.DefaultFolder, zcamtest.NewClip("ZCAM0000_0000_202405010900.MOV", time.Now(), time.Second))
+	srv.Camera.SetValue(settings.SplitDurationSetting, "10")
+	srv.Camera.SetValue(settings.RecProxyFileSetting, "On")
+
+	// every real millisecond is 150s of the camera clock
+	start := time.Now()
+	srv.Camera.Now = func() time.Time {
+		return start.Add(time.Since(start) * 150000)
+	}
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	r, err := cli.Record(context.Background(), 10*time.Millisecond)
+	require.NoError(t, err)
+	require.Greater(t, len(r.Segments), 1)
+	require.Len(t, r.Files(), len(r.Segments)*2)
+
+	for i, s := range r.Segments {
+		require.Equal(t, zcamtest.DefaultFolder, s.Main.Folder())
+		require.Regexp(t, fmt.Sprintf(`^ZCAM0001_%04d_\d+\.MOV$`, i), s.Main.Filename())
+		require.Equal(t, strings.TrimSuffix(s.Main.Filename(), ".MOV")+"_proxy.MOV", s.Proxy.Filename())
+	}
+
+	require.Equal(t, r.Segments[len(r.Segments)-1].Main, r.Last())
+}
+
+func TestNewRecording(t *testing.T) {
+	files := []*File{
+		{folder: "100MEDIA", file: "ZCAM0001_0001_202405011000_proxy.MOV"},
+		{folder: "100MEDIA", file: "ZCAM0001_0001_202405011000.MOV"},
+		{folder: "100MEDIA", file: "ZCAM0001_0000_202405011000.MOV"},
+		{folder: "100MEDIA", file: "ZCAM0002_0000_202405011000.JPG"},
+	}
+
+	r := newRecording(files)
+	require.Equal(t, &Recording{Segments: []*Clip{
+		{Main: files[2]},
+		{Main: files[1], Proxy: files[0]},
+	}}, r)
+}
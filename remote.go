@@ -0,0 +1,205 @@
+package zcam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// ErrRangeNotSupported is returned by RemoteFile when the camera ignores the
+// Range requests.
+var ErrRangeNotSupported = errors.New("range requests not supported")
+
+const (
+	// remoteBlockSize is the size of the blocks requested by RemoteFile.
+	remoteBlockSize = 64 << 10
+	// remoteCacheBlocks is the number of blocks cached by RemoteFile.
+	remoteCacheBlocks = 16
+)
+
+// RemoteFile is a file of the camera accessed with Range requests, it
+// implements io.ReaderAt and io.Seeker, allowing to read any part of a clip,
+// such as the moov atom at the end of a MOV file, without downloading it
+// whole. The last blocks read are cached. It's safe for concurrent use by
+// ReadAt, but not by Read and Seek.
+type RemoteFile struct {
+	ctx       context.Context
+	c         *Camera
+	endpoint  string
+	size      int64
+	blockSize int64
+	offset    int64
+
+	mu     sync.Mutex
+	blocks map[int64][]byte
+	// recent are the indexes of the cached blocks, the most recently used
+	// last.
+	recent []int64
+}
+
+// OpenRemote returns a RemoteFile to read the original file, the context is
+// used by every request made by it.
+func (f *File) OpenRemote(ctx context.Context) (*RemoteFile, error) {
+	size, err := f.Size(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve size of %q in folder %q: %w", f.file, f.folder, err)
+	}
+
+	return &RemoteFile{
+		ctx:       ctx,
+		c:         f.c,
+		endpoint:  fmt.Sprintf(RootFolder+"%s/%s", f.folder, f.file),
+		size:      size,
+		blockSize: remoteBlockSize,
+		blocks:    make(map[int64][]byte),
+	}, nil
+}
+
+// Size returns the size of the file in bytes.
+func (r *RemoteFile) Size() int64 {
+	return r.size
+}
+
+// ReadAt implements io.ReaderAt.
+func (r *RemoteFile) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset %d", off)
+	}
+
+	var n int
+	for n < len(p) && off < r.size {
+		idx := off / r.blockSize
+		b, err := r.block(idx)
+		if err != nil {
+			return n, err
+		}
+
+		copied := copy(p[n:], b[off-idx*r.blockSize:])
+		n += copied
+		off += int64(copied)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// Read implements io.Reader, reading from the current offset.
+func (r *RemoteFile) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+
+	n, err := r.ReadAt(p, r.offset)
+	r.offset += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+
+	return n, err
+}
+
+// Seek implements io.Seeker, setting the offset of the next Read.
+func (r *RemoteFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+
+	if offset < 0 {
+		return 0, fmt.Errorf("negative position %d", offset)
+	}
+
+	r.offset = offset
+	return offset, nil
+}
+
+// block returns the content of the given block, from the cache or requesting
+// it to the camera.
+func (r *RemoteFile) block(idx int64) ([]byte, error) {
+	r.mu.Lock()
+	b, ok := r.blocks[idx]
+	if ok {
+		r.touch(idx)
+	}
+	r.mu.Unlock()
+
+	if ok {
+		return b, nil
+	}
+
+	b, err := r.fetch(idx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.blocks[idx]; !ok && len(r.recent) >= remoteCacheBlocks {
+		delete(r.blocks, r.recent[0])
+		r.recent = r.recent[1:]
+	}
+
+	r.blocks[idx] = b
+	r.touch(idx)
+	return b, nil
+}
+
+// touch moves the block to the end of the recently used ones.
+func (r *RemoteFile) touch(idx int64) {
+	for i, v := range r.recent {
+		if v == idx {
+			r.recent = append(r.recent[:i], r.recent[i+1:]...)
+			break
+		}
+	}
+
+	r.recent = append(r.recent, idx)
+}
+
+func (r *RemoteFile) fetch(idx int64) ([]byte, error) {
+	start := idx * r.blockSize
+	end := start + r.blockSize
+	if end > r.size {
+		end = r.size
+	}
+
+	header := http.Header{"Range": {fmt.Sprintf("bytes=%d-%d", start, end-1)}}
+	resp, err := r.c.getResponse(r.ctx, r.endpoint, header)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return nil, ErrRangeNotSupported
+	}
+
+	first, _, err := parseContentRange(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if first != start {
+		return nil, fmt.Errorf("unexpected Content-Range %q, requested from %d", resp.Header.Get("Content-Range"), start)
+	}
+
+	b := make([]byte, end-start)
+	if _, err := io.ReadFull(resp.Body, b); err != nil {
+		return nil, fmt.Errorf("error reading block %d: %w", idx, err)
+	}
+
+	return b, nil
+}
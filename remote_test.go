@@ -0,0 +1,66 @@
+package zcam
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteFile(t *testing.T) {
+	_, f, data := newDownloadFile(t)
+
+	var mu sync.Mutex
+	var ranges []string
+	f.c.Client.Transport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		ranges = append(ranges, r.Method+" "+r.Header.Get("Range"))
+		mu.Unlock()
+
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	r, err := f.OpenRemote(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), r.Size())
+	r.blockSize = 1024
+
+	// the last bytes, as a parser looking for the moov atom would read them
+	buf := make([]byte, 100)
+	n, err := r.ReadAt(buf, r.Size()-100)
+	require.NoError(t, err)
+	require.Equal(t, 100, n)
+	require.Equal(t, data[len(data)-100:], buf)
+
+	n, err = r.ReadAt(buf, r.Size()-50)
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, 50, n)
+	require.Equal(t, data[len(data)-50:], buf[:50])
+
+	require.Equal(t, []string{"HEAD ", "GET bytes=9216-10239"}, ranges)
+
+	pos, err := r.Seek(1000, io.SeekStart)
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), pos)
+
+	buf = make([]byte, 100)
+	_, err = io.ReadFull(r, buf)
+	require.NoError(t, err)
+	require.Equal(t, data[1000:1100], buf)
+
+	pos, err = r.Seek(-10, io.SeekEnd)
+	require.NoError(t, err)
+	require.Equal(t, r.Size()-10, pos)
+
+	rest, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, data[len(data)-10:], rest)
+
+	all, err := io.ReadAll(io.NewSectionReader(r, 0, r.Size()))
+	require.NoError(t, err)
+	require.Equal(t, data, all)
+	require.Len(t, ranges, 11)
+}
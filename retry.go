@@ -0,0 +1,149 @@
+package zcam
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy configures the retries of the requests failing with a transient
+// error, such as the ones returned while the camera is switching modes or
+// writing to the card.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry, doubled on every
+	// retry up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Jitter randomizes the backoff by the given fraction, between 0 and 1.
+	Jitter float64
+	// Retryable reports whether an error should be retried, IsRetryable is
+	// used if nil. The errors returned by the camera as a non-zero code in
+	// the body are passed as *APIError.
+	Retryable func(error) bool
+	// RetryNonIdempotent enables the retries of the commands that are not
+	// safe to repeat, such as starting a recording, formatting the card or
+	// deleting a file. Disabled by default, since a retry of a command that
+	// reached the camera may have unexpected results.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy returns a RetryPolicy with 4 attempts, backing off from
+// 250ms up to 2s.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: 250 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Jitter:         0.2,
+	}
+}
+
+// IsRetryable reports whether the error is considered transient: the camera
+// is busy (ErrBusy, the 503 HTTP status code), any other 5xx HTTP status
+// code, a network timeout or a dropped connection. The commands rejected
+// with a non-zero code are not retried, the code doesn't tell if the failure
+// is transient.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, ErrBusy) {
+		return true
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatus >= http.StatusInternalServerError
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+
+	return IsRetryable(err)
+}
+
+// backoff returns the wait before the given retry, starting at 1.
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	d := float64(p.InitialBackoff) * math.Pow(2, float64(retry-1))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(d)
+}
+
+// nonIdempotent are the endpoints that should not be repeated without the
+// explicit consent of the user.
+var nonIdempotent = []string{
+	"/ctrl/rec?action=start",
+	"/ctrl/still",
+	"/ctrl/card?action=format",
+	"/ctrl/card?action=fat32",
+	"/ctrl/card?action=exfat",
+	"/ctrl/shutdown",
+	"/ctrl/reboot",
+	"/ctrl/set?mf_drive=",
+}
+
+func isIdempotent(endpoint string) bool {
+	if strings.HasPrefix(endpoint, RootFolder) && strings.HasSuffix(endpoint, "?act=rm") {
+		return false
+	}
+
+	for _, prefix := range nonIdempotent {
+		if strings.HasPrefix(endpoint, prefix) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// retry calls fn until it succeeds or the policy gives up, returning the last
+// error.
+func (c *Camera) retry(ctx context.Context, endpoint string, fn func() error) error {
+	p := c.Retry
+	if p == nil || p.MaxAttempts <= 1 || (!p.RetryNonIdempotent && !isIdempotent(endpoint)) {
+		return fn()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.MaxAttempts || !p.retryable(err) {
+			return err
+		}
+
+		select {
+		case <-time.After(p.backoff(attempt)):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
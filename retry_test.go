@@ -0,0 +1,136 @@
+package zcam
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mcuadros/go-zcam-e2/zcamtest"
+	"github.com/stretchr/testify/require"
+)
+
+func newRetryCamera(srv *zcamtest.Server) *Camera {
+	cli := NewCamera(srv.Listener.Addr().String())
+	cli.Retry = &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	return cli
+}
+
+func TestRetryHTTPStatus(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/info", StatusCode: http.StatusServiceUnavailable, Count: 2})
+	cli := newRetryCamera(srv)
+
+	_, err := cli.GetCameraInfo(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, srv.Faults.Injected())
+}
+
+func TestRetryGiveUp(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/info", StatusCode: http.StatusBadGateway})
+	cli := newRetryCamera(srv)
+
+	_, err := cli.GetCameraInfo(context.Background())
+	require.Error(t, err)
+	require.Equal(t, 3, srv.Faults.Injected())
+}
+
+func TestRetryNotRetryable(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	cli := newRetryCamera(srv)
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/ctrl/temperature", Code: -1})
+
+	_, err := cli.QueryTemperature(context.Background())
+	require.True(t, isRejected(err))
+	require.Equal(t, 1, srv.Faults.Injected())
+}
+
+func TestRetrySameErrors(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	for _, fault := range []zcamtest.Fault{
+		{Endpoint: "/info", Code: -1, Desc: "failed"},
+		{Endpoint: "/info", StatusCode: http.StatusServiceUnavailable},
+	} {
+		var errs []error
+		for _, cli := range []*Camera{NewCamera(srv.Listener.Addr().String()), newRetryCamera(srv)} {
+			srv.Faults.Reset()
+			srv.Faults.Add(fault)
+
+			_, err := cli.GetCameraInfo(context.Background())
+			require.Error(t, err)
+			errs = append(errs, err)
+		}
+
+		require.Equal(t, errs[0], errs[1])
+	}
+}
+
+func TestRetryNonIdempotent(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/ctrl/rec?action=start", StatusCode: http.StatusServiceUnavailable, Count: 1})
+	cli := newRetryCamera(srv)
+
+	require.ErrorIs(t, cli.StartVideoRecord(context.Background()), ErrBusy)
+	require.False(t, srv.Camera.IsRecording())
+
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/ctrl/rec?action=start", StatusCode: http.StatusServiceUnavailable, Count: 1})
+	cli.Retry.RetryNonIdempotent = true
+
+	require.NoError(t, cli.StartVideoRecord(context.Background()))
+	require.True(t, srv.Camera.IsRecording())
+}
+
+func TestRetryCustomRetryable(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/info", Code: -7, Count: 1})
+	cli := newRetryCamera(srv)
+	cli.Retry.Retryable = func(err error) bool {
+		apiErr, ok := err.(*APIError)
+		return ok && apiErr.Code == -7
+	}
+
+	temp, err := cli.QueryTemperature(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 45, temp)
+}
+
+func TestIsIdempotent(t *testing.T) {
+	for endpoint, expected := range map[string]bool{
+		"/info":                        true,
+		"/ctrl/rec?action=remain":      true,
+		"/ctrl/rec?action=start":       false,
+		"/ctrl/rec?action=stop":        true,
+		"/ctrl/card?action=format":     false,
+		"/ctrl/card?action=query_free": true,
+		"/DCIM/100MEDIA/A.MOV?act=rm":  false,
+		"/DCIM/100MEDIA/A.MOV?act=ct":  true,
+	} {
+		require.Equal(t, expected, isIdempotent(endpoint), fmt.Sprintf("endpoint %s", endpoint))
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	require.Equal(t, 100*time.Millisecond, p.backoff(1))
+	require.Equal(t, 200*time.Millisecond, p.backoff(2))
+	require.Equal(t, 300*time.Millisecond, p.backoff(3))
+
+	p.Jitter = 0.5
+	for i := 0; i < 10; i++ {
+		require.InDelta(t, float64(200*time.Millisecond), float64(p.backoff(2)), float64(100*time.Millisecond))
+	}
+}
@@ -0,0 +1,72 @@
+package settings
+
+// Category groups the settings, following the camera menus.
+type Category string
+
+// Categories of the settings.
+const (
+	VideoCategory          Category = "video"
+	FocusCategory          Category = "focus"
+	ExposureCategory       Category = "exposure"
+	WhiteBalanceCategory   Category = "white balance"
+	ImageCategory          Category = "image"
+	StreamCategory         Category = "stream"
+	AudioCategory          Category = "audio"
+	TimecodeCategory       Category = "timecode"
+	AssistToolCategory     Category = "assist tool"
+	MiscCategory           Category = "misc"
+	MultipleCameraCategory Category = "multiple camera"
+	PhotoCategory          Category = "photo"
+	// OtherCategory contains the settings found in the capability dumps that
+	// are not declared in settings.go.
+	OtherCategory Category = "other"
+)
+
+// Categories contains every category, in the order they are declared.
+var Categories = []Category{
+	VideoCategory,
+	FocusCategory,
+	ExposureCategory,
+	WhiteBalanceCategory,
+	ImageCategory,
+	StreamCategory,
+	AudioCategory,
+	TimecodeCategory,
+	AssistToolCategory,
+	MiscCategory,
+	MultipleCameraCategory,
+	PhotoCategory,
+	OtherCategory,
+}
+
+var settingCategories = func() map[Setting]Category {
+	m := make(map[Setting]Category)
+	for c, keys := range categorySettings {
+		for _, key := range keys {
+			m[key] = c
+		}
+	}
+
+	return m
+}()
+
+// All contains every known setting, by category in the order they are
+// declared.
+var All = func() []Setting {
+	var all []Setting
+	for _, c := range Categories {
+		all = append(all, categorySettings[c]...)
+	}
+
+	return all
+}()
+
+// Category returns the category of the setting, empty if unknown.
+func (s Setting) Category() Category {
+	return settingCategories[s]
+}
+
+// ByCategory returns the settings of the given category.
+func ByCategory(c Category) []Setting {
+	return append([]Setting(nil), categorySettings[c]...)
+}
@@ -0,0 +1,514 @@
+// Command settingsgen generates the category metadata and the option
+// constants of the settings package.
+//
+// Usage:
+//
+//	settingsgen [-dir dir] [-out file] [-options] <dump.json>...
+//
+// The setting constants and their categories are read from the const blocks
+// of the package, annotated with a "//settings:category <name>" directive.
+// The dumps are the output of "zcam -json caps", the settings not declared in
+// the package get a new constant, on the other category. With -options every
+// choice setting gets a constant per option, it must be used only with dumps
+// of real cameras, since the constants are part of the API.
+//
+// The metadata registry is built from the doc comments of the constants,
+// such as "ISOSetting sets the ISO mode (type: choice)", and from the dumps,
+// taking precedence the kind and read-only status reported by the cameras.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	choiceType    = 1
+	rangeType     = 2
+	otherCategory = "other"
+)
+
+// typeComment matches the "(type: choice, ...)" suffix of the doc comments.
+var typeComment = regexp.MustCompile(`\s*\(type: (\w+)[^)]*\)`)
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the settings package")
+	out := flag.String("out", "zz_generated.go", "output file, relative to -dir")
+	options := flag.Bool("options", false, "generate the option constants of the choice settings")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		log.Fatal("usage: settingsgen [-dir dir] [-out file] [-options] <dump.json>...")
+	}
+
+	pkg, err := parsePackage(*dir, *out)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var dumps []*dump
+	for _, filename := range flag.Args() {
+		d, err := readDump(filename)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		dumps = append(dumps, d)
+	}
+
+	src, err := generate(pkg, mergeDumps(dumps...), *options)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(*dir, *out), src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// setting is a setting constant declared in the package.
+type setting struct {
+	name        string
+	key         string
+	category    string
+	kind        string
+	readOnly    bool
+	description string
+}
+
+// pkgInfo holds the declarations of the settings package.
+type pkgInfo struct {
+	settings []setting
+	// categories are the names of the Category constants by value.
+	categories map[string]string
+	// idents are all the top-level identifiers.
+	idents map[string]bool
+}
+
+func (p *pkgInfo) setting(key string) (setting, bool) {
+	for _, s := range p.settings {
+		if s.key == key {
+			return s, true
+		}
+	}
+
+	return setting{}, false
+}
+
+// parsePackage reads the declarations of the package, ignoring the generated
+// file and the tests.
+func parsePackage(dir, generated string) (*pkgInfo, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+
+	p := &pkgInfo{categories: make(map[string]string), idents: make(map[string]bool)}
+	fset := token.NewFileSet()
+	for _, filename := range files {
+		if filepath.Base(filename) == generated || strings.HasSuffix(filename, "_test.go") {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, decl := range f.Decls {
+			if err := p.parseDecl(decl); err != nil {
+				return nil, fmt.Errorf("%s: %w", fset.Position(decl.Pos()), err)
+			}
+		}
+	}
+
+	for _, s := range p.settings {
+		if _, ok := p.categories[s.category]; !ok {
+			return nil, fmt.Errorf("unknown category %q of %s", s.category, s.name)
+		}
+	}
+
+	return p, nil
+}
+
+func (p *pkgInfo) parseDecl(decl ast.Decl) error {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Recv == nil {
+			p.idents[d.Name.Name] = true
+		}
+
+		return nil
+	case *ast.GenDecl:
+		category := directive(d.Doc, "settings:category")
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				p.idents[s.Name.Name] = true
+			case *ast.ValueSpec:
+				if err := p.parseValueSpec(d.Tok, s, category); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *pkgInfo) parseValueSpec(tok token.Token, s *ast.ValueSpec, category string) error {
+	for _, name := range s.Names {
+		p.idents[name.Name] = true
+	}
+
+	typ, ok := s.Type.(*ast.Ident)
+	if tok != token.CONST || !ok || len(s.Names) != 1 || len(s.Values) != 1 {
+		return nil
+	}
+
+	lit, ok := s.Values[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return nil
+	}
+
+	value, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return err
+	}
+
+	switch typ.Name {
+	case "Setting":
+		if category == "" {
+			return fmt.Errorf("missing settings:category directive of %s", s.Names[0].Name)
+		}
+
+		st := setting{name: s.Names[0].Name, key: value, category: category}
+		st.parseDoc(s.Doc)
+		p.settings = append(p.settings, st)
+	case "Category":
+		p.categories[value] = s.Names[0].Name
+	}
+
+	return nil
+}
+
+// parseDoc reads the description, kind and read-only status from a doc
+// comment such as "XSetting shows the X, read-only (type: string).".
+func (s *setting) parseDoc(doc *ast.CommentGroup) {
+	if doc == nil {
+		return
+	}
+
+	text := strings.Join(strings.Fields(doc.Text()), " ")
+	if m := typeComment.FindStringSubmatch(text); m != nil {
+		s.kind = m[1]
+		text = typeComment.ReplaceAllString(text, "")
+	}
+
+	// the first word is the name of the constant
+	_, text, _ = strings.Cut(text, " ")
+	text = strings.TrimSuffix(strings.TrimSpace(text), ".")
+	s.readOnly = strings.HasSuffix(text, ", read-only")
+	text = strings.TrimSuffix(text, ", read-only")
+	if text != "" {
+		s.description = strings.ToUpper(text[:1]) + text[1:] + "."
+	}
+}
+
+// directive returns the argument of the given directive in the comments.
+func directive(doc *ast.CommentGroup, name string) string {
+	if doc == nil {
+		return ""
+	}
+
+	for _, c := range doc.List {
+		if arg, ok := strings.CutPrefix(c.Text, "//"+name+" "); ok {
+			return strings.TrimSpace(arg)
+		}
+	}
+
+	return ""
+}
+
+// dump is the JSON output of "zcam -json caps".
+type dump struct {
+	Model    string       `json:"model"`
+	Firmware string       `json:"firmware"`
+	Settings []dumpSchema `json:"settings"`
+}
+
+type dumpSchema struct {
+	Key      string   `json:"key"`
+	Type     int      `json:"type"`
+	ReadOnly bool     `json:"read_only"`
+	Options  []string `json:"options"`
+}
+
+func readDump(filename string) (*dump, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &dump{}
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, fmt.Errorf("error decoding %s: %w", filename, err)
+	}
+
+	return d, nil
+}
+
+// mergeDumps returns the union of the settings, the options are merged
+// keeping the order they were found.
+func mergeDumps(dumps ...*dump) []dumpSchema {
+	var merged []dumpSchema
+	index := make(map[string]int)
+	for _, d := range dumps {
+		for _, s := range d.Settings {
+			i, ok := index[s.Key]
+			if !ok {
+				index[s.Key] = len(merged)
+				merged = append(merged, dumpSchema{Key: s.Key, Type: s.Type, ReadOnly: s.ReadOnly})
+				i = len(merged) - 1
+			}
+
+			m := &merged[i]
+			m.ReadOnly = m.ReadOnly && s.ReadOnly
+			for _, opt := range s.Options {
+				if !contains(m.Options, opt) {
+					m.Options = append(m.Options, opt)
+				}
+			}
+		}
+	}
+
+	return merged
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// generate returns the source of the generated file, including the option
+// constants if options is true.
+func generate(p *pkgInfo, schemas []dumpSchema, options bool) ([]byte, error) {
+	idents := make(map[string]bool, len(p.idents))
+	for k := range p.idents {
+		idents[k] = true
+	}
+
+	declare := func(name string) error {
+		if idents[name] {
+			return fmt.Errorf("identifier %s already declared", name)
+		}
+
+		idents[name] = true
+		return nil
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by settingsgen. DO NOT EDIT.\n\npackage settings\n\n")
+
+	settings := append([]setting(nil), p.settings...)
+	var others []dumpSchema
+	for _, s := range schemas {
+		if _, ok := p.setting(s.Key); !ok {
+			others = append(others, s)
+		}
+	}
+
+	sort.Slice(others, func(i, j int) bool { return others[i].Key < others[j].Key })
+	if len(others) != 0 {
+		fmt.Fprintf(&buf, "// Settings found in the capability dumps, not declared in settings.go.\nconst (\n")
+		for _, s := range others {
+			name := identifier(s.Key) + "Setting"
+			if err := declare(name); err != nil {
+				return nil, err
+			}
+
+			fmt.Fprintf(&buf, "\t// %s (%s).\n", name, describe(s))
+			fmt.Fprintf(&buf, "\t%s Setting = %q\n", name, s.Key)
+			settings = append(settings, setting{name: name, key: s.Key, category: otherCategory, kind: kindName(s.Type)})
+		}
+
+		fmt.Fprintf(&buf, ")\n\n")
+	}
+
+	if err := writeCategories(&buf, p, settings); err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]dumpSchema, len(schemas))
+	for _, s := range schemas {
+		byKey[s.Key] = s
+	}
+
+	if err := writeRegistry(&buf, settings, byKey); err != nil {
+		return nil, err
+	}
+
+	if options {
+		if err := writeOptions(&buf, settings, byKey, declare); err != nil {
+			return nil, err
+		}
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// writeOptions writes a const block with the options of every writable choice
+// setting, declaring the names with declare.
+func writeOptions(buf *bytes.Buffer, settings []setting, schemas map[string]dumpSchema, declare func(string) error) error {
+	for _, s := range settings {
+		schema, ok := schemas[s.key]
+		if !ok || schema.Type != choiceType || schema.ReadOnly || len(schema.Options) == 0 {
+			continue
+		}
+
+		prefix := strings.TrimSuffix(s.name, "Setting")
+		fmt.Fprintf(buf, "\n// Options of %s.\nconst (\n", s.name)
+		for _, opt := range schema.Options {
+			if identifier(opt) == "" {
+				return fmt.Errorf("option %q of %s can't be converted to an identifier", opt, s.key)
+			}
+
+			name := prefix + identifier(opt)
+			if err := declare(name); err != nil {
+				return fmt.Errorf("option %q of %s: %w", opt, s.key, err)
+			}
+
+			fmt.Fprintf(buf, "\t%s = %q\n", name, opt)
+		}
+
+		fmt.Fprintf(buf, ")\n")
+	}
+
+	return nil
+}
+
+func writeCategories(buf *bytes.Buffer, p *pkgInfo, settings []setting) error {
+	var order []string
+	byCategory := make(map[string][]string)
+	for _, s := range settings {
+		if _, ok := byCategory[s.category]; !ok {
+			order = append(order, s.category)
+		}
+
+		byCategory[s.category] = append(byCategory[s.category], s.name)
+	}
+
+	fmt.Fprintf(buf, "var categorySettings = map[Category][]Setting{\n")
+	for _, category := range order {
+		name, ok := p.categories[category]
+		if !ok {
+			return fmt.Errorf("unknown category %q", category)
+		}
+
+		fmt.Fprintf(buf, "\t%s: {\n", name)
+		for _, s := range byCategory[category] {
+			fmt.Fprintf(buf, "\t\t%s,\n", s)
+		}
+
+		fmt.Fprintf(buf, "\t},\n")
+	}
+
+	fmt.Fprintf(buf, "}\n")
+	return nil
+}
+
+func writeRegistry(buf *bytes.Buffer, settings []setting, schemas map[string]dumpSchema) error {
+	fmt.Fprintf(buf, "\nvar registry = map[Setting]Metadata{\n")
+	for _, s := range settings {
+		kind, readOnly := s.kind, s.readOnly
+		schema, ok := schemas[s.key]
+		if ok {
+			kind, readOnly = kindName(schema.Type), schema.ReadOnly
+		}
+
+		if kind != "choice" && kind != "range" && kind != "string" {
+			return fmt.Errorf("unknown kind %q of %s", kind, s.name)
+		}
+
+		fmt.Fprintf(buf, "\t%s: {\n\t\tKind: %sKind,\n", s.name, strings.ToUpper(kind[:1])+kind[1:])
+		if readOnly {
+			fmt.Fprintf(buf, "\t\tReadOnly: true,\n")
+		}
+
+		if s.description != "" {
+			fmt.Fprintf(buf, "\t\tDescription: %q,\n", s.description)
+		}
+
+		fmt.Fprintf(buf, "\t},\n")
+	}
+
+	fmt.Fprintf(buf, "}\n")
+	return nil
+}
+
+func kindName(typ int) string {
+	switch typ {
+	case choiceType:
+		return "choice"
+	case rangeType:
+		return "range"
+	default:
+		return "string"
+	}
+}
+
+func describe(s dumpSchema) string {
+	if s.Type == choiceType {
+		return "type: choice, options: " + strings.Join(s.Options, "/")
+	}
+
+	return "type: " + kindName(s.Type)
+}
+
+// identifier converts a key or option into a Go identifier, such as
+// "max_iso" into "MaxIso", "Max ISO" into "MaxISO", "1/50" into "1_50" and
+// "-3" into "Minus3".
+func identifier(s string) string {
+	var b strings.Builder
+	if strings.HasPrefix(s, "-") {
+		b.WriteString("Minus")
+	} else if strings.HasPrefix(s, "+") {
+		b.WriteString("Plus")
+	}
+
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, w := range words {
+		// the numbers are kept apart, such as "1_50", "172_8" or "0_255"
+		if i > 0 && unicode.IsDigit(rune(w[0])) && unicode.IsDigit(rune(words[i-1][len(words[i-1])-1])) {
+			b.WriteByte('_')
+		}
+
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+
+	return b.String()
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentifier(t *testing.T) {
+	for input, expected := range map[string]string{
+		"max_iso":        "MaxIso",
+		"Max ISO":        "MaxISO",
+		"1/50":           "1_50",
+		"172.8":          "172_8",
+		"-3":             "Minus3",
+		"4K (Low Noise)": "4KLowNoise",
+		"CH1 & CH2":      "CH1CH2",
+		"free run":       "FreeRun",
+		"H.265":          "H265",
+	} {
+		require.Equal(t, expected, identifier(input), input)
+	}
+}
+
+const testPackage = `package settings
+
+type Setting string
+
+type Category string
+
+const (
+	VideoCategory Category = "video"
+	OtherCategory Category = "other"
+)
+
+// Video settings
+//
+//settings:category video
+const (
+	// ISOSetting sets the ISO mode (type: choice, options: Auto/400/...).
+	ISOSetting Setting = "iso"
+	// WBSetting shows the white balance, read-only (type: choice).
+	WBSetting Setting = "wb"
+)
+`
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "settings.go"), []byte(testPackage), 0o644))
+
+	p, err := parsePackage(dir, "zz_generated.go")
+	require.NoError(t, err)
+
+	schemas := mergeDumps(
+		&dump{Model: "E2", Settings: []dumpSchema{
+			{Key: "iso", Type: choiceType, Options: []string{"Auto", "400"}},
+			{Key: "wb", Type: choiceType, ReadOnly: true, Options: []string{"Auto"}},
+		}},
+		&dump{Model: "E2F6", Settings: []dumpSchema{
+			{Key: "iso", Type: choiceType, Options: []string{"400", "800"}},
+			{Key: "new_key", Type: 2},
+		}},
+	)
+
+	src, err := generate(p, schemas, true)
+	require.NoError(t, err)
+	require.Equal(t, `// Code generated by settingsgen. DO NOT EDIT.
+
+package settings
+
+// Settings found in the capability dumps, not declared in settings.go.
+const (
+	// NewKeySetting (type: range).
+	NewKeySetting Setting = "new_key"
+)
+
+var categorySettings = map[Category][]Setting{
+	VideoCategory: {
+		ISOSetting,
+		WBSetting,
+	},
+	OtherCategory: {
+		NewKeySetting,
+	},
+}
+
+var registry = map[Setting]Metadata{
+	ISOSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the ISO mode.",
+	},
+	WBSetting: {
+		Kind:        ChoiceKind,
+		ReadOnly:    true,
+		Description: "Shows the white balance.",
+	},
+	NewKeySetting: {
+		Kind: RangeKind,
+	},
+}
+
+// Options of ISOSetting.
+const (
+	ISOAuto = "Auto"
+	ISO400  = "400"
+	ISO800  = "800"
+)
+`, string(src))
+
+	src, err = generate(p, schemas, false)
+	require.NoError(t, err)
+	require.NotContains(t, string(src), "Options of")
+	require.NotContains(t, string(src), "ISOAuto")
+	require.Contains(t, string(src), "NewKeySetting Setting = \"new_key\"")
+}
+
+func TestGenerateCollision(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "settings.go"), []byte(testPackage), 0o644))
+
+	p, err := parsePackage(dir, "zz_generated.go")
+	require.NoError(t, err)
+
+	_, err = generate(p, []dumpSchema{
+		{Key: "iso", Type: choiceType, Options: []string{"1/50", "1-50"}},
+	}, true)
+
+	require.ErrorContains(t, err, "ISO1_50 already declared")
+}
@@ -0,0 +1,39 @@
+package settings
+
+// Kind is the kind of value of a setting.
+type Kind string
+
+// Kinds of the settings values.
+const (
+	ChoiceKind Kind = "choice"
+	RangeKind  Kind = "range"
+	StringKind Kind = "string"
+)
+
+// Metadata describes a setting, it's generated from the doc comments of
+// settings.go and the capability dumps.
+type Metadata struct {
+	Key      Setting
+	Category Category
+	Kind     Kind
+	ReadOnly bool
+	// Description is a human readable description.
+	Description string
+}
+
+// Metadata returns the metadata of the setting, false if it's unknown.
+func (s Setting) Metadata() (Metadata, bool) {
+	m, ok := registry[s]
+	if !ok {
+		return Metadata{}, false
+	}
+
+	m.Key = s
+	m.Category = s.Category()
+	return m, true
+}
+
+// Description returns the description of the setting, empty if unknown.
+func (s Setting) Description() string {
+	return registry[s].Description
+}
@@ -0,0 +1,86 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadata(t *testing.T) {
+	for _, tc := range []struct {
+		key      Setting
+		expected Metadata
+		ok       bool
+	}{
+		{ISOSetting, Metadata{
+			Key: ISOSetting, Category: ExposureCategory, Kind: ChoiceKind,
+			Description: "Sets the ISO mode.",
+		}, true},
+		{MWBSetting, Metadata{
+			Key: MWBSetting, Category: WhiteBalanceCategory, Kind: RangeKind,
+			Description: "Sets the manual white balance in kelvin.",
+		}, true},
+		{BatterySetting, Metadata{
+			Key: BatterySetting, Category: MiscCategory, Kind: RangeKind, ReadOnly: true,
+			Description: "Shows the battery percentage.",
+		}, true},
+		{Setting("unknown"), Metadata{}, false},
+	} {
+		m, ok := tc.key.Metadata()
+		require.Equal(t, tc.ok, ok, tc.key)
+		require.Equal(t, tc.expected, m, tc.key)
+		require.Equal(t, tc.expected.Description, tc.key.Description(), tc.key)
+	}
+}
+
+func TestCategory(t *testing.T) {
+	for _, tc := range []struct {
+		key      Setting
+		expected Category
+	}{
+		{MovFmtSetting, VideoCategory},
+		{ISOSetting, ExposureCategory},
+		{WBSetting, WhiteBalanceCategory},
+		{BatterySetting, MiscCategory},
+		{Setting("unknown"), ""},
+	} {
+		require.Equal(t, tc.expected, tc.key.Category(), tc.key)
+	}
+}
+
+func TestAll(t *testing.T) {
+	seen := make(map[Setting]bool, len(All))
+	for _, key := range All {
+		require.False(t, seen[key], "duplicated %s", key)
+		seen[key] = true
+
+		m, ok := key.Metadata()
+		require.True(t, ok, key)
+		require.NotEmpty(t, m.Category, key)
+		require.Contains(t, []Kind{ChoiceKind, RangeKind, StringKind}, m.Kind, key)
+	}
+
+	require.Len(t, registry, len(All))
+	require.Equal(t, MovFmtSetting, All[0])
+}
+
+func TestByCategory(t *testing.T) {
+	var total int
+	for _, c := range Categories {
+		keys := ByCategory(c)
+		for _, key := range keys {
+			require.Equal(t, c, key.Category(), key)
+		}
+
+		total += len(keys)
+	}
+
+	require.Equal(t, len(All), total)
+	require.Contains(t, ByCategory(ExposureCategory), ISOSetting)
+	require.Empty(t, ByCategory(Category("unknown")))
+
+	// the result is a copy, modifying it doesn't alter the package
+	keys := ByCategory(VideoCategory)
+	keys[0] = ISOSetting
+	require.Equal(t, MovFmtSetting, ByCategory(VideoCategory)[0])
+}
@@ -1,11 +1,24 @@
+// Package settings contains the keys of the camera settings.
+//
+// The categories and the metadata are generated from the const blocks of this
+// file and the capability dumps at capabilities/, run "go generate" after
+// changing them.
+//
+// The only dump available, capabilities/zcamtest.json, is taken from the
+// zcamtest emulator and not from a real camera, so no option constants are
+// generated from it, the options emulated may not match the ones of any E2
+// firmware. Once the dumps of real cameras, as written by "zcam caps -json",
+// are added, the generator can be run with -options.
 package settings
 
-import "fmt"
+//go:generate go run ./internal/settingsgen -out zz_generated.go capabilities/zcamtest.json
 
 // Setting represents a camera setting.
 type Setting string
 
 // Video settings
+//
+//settings:category video
 const (
 	// MovFmtSetting sets the format (type: choice, options: 4KP30/4KP60/...).
 	MovFmtSetting Setting = "movfmt"
@@ -40,6 +53,8 @@ const (
 )
 
 // Focus & Zoom settings
+//
+//settings:category focus
 const (
 	// FocusSetting sets the focus mode (type: choice, options: AF/MF).
 	FocusSetting Setting = "focus"
@@ -72,6 +87,8 @@ const (
 )
 
 // Exposure settings
+//
+//settings:category exposure
 const (
 	// MeterModeSetting sets the automatic exposure meter mode (type: choice).
 	MeterModeSetting Setting = "meter_mode"
@@ -108,6 +125,8 @@ const (
 )
 
 // White Balance settings
+//
+//settings:category white balance
 const (
 	// WBSetting sets the white balance mode (type: choice, options: Auto/Manual).
 	WBSetting Setting = "wb"
@@ -126,6 +145,8 @@ const (
 )
 
 // Image settings
+//
+//settings:category image
 const (
 	// SharpnessSetting sets the sharpness level (type: choice, options: Strong/Normal/Weak).
 	SharpnessSetting Setting = "sharpness"
@@ -144,12 +165,16 @@ const (
 )
 
 // Stream settings
+//
+//settings:category stream
 const (
 	// SendStreamSetting selects the stream (type: choice, options: stream0/stream1).
 	SendStreamSetting Setting = "send_stream"
 )
 
 // Audio settings
+//
+//settings:category audio
 const (
 	// PrimaryAudioSetting sets the primary audio format (type: choice, options: AAC/PCM).
 	PrimaryAudioSetting Setting = "primary_audio"
@@ -166,6 +191,8 @@ const (
 )
 
 // Timecode settings
+//
+//settings:category timecode
 const (
 	// TCCountUpSetting sets the timecode count mode (type: choice, options: free run/record run).
 	TCCountUpSetting Setting = "tc_count_up"
@@ -176,6 +203,8 @@ const (
 )
 
 // Assist tool settings
+//
+//settings:category assist tool
 const (
 	// AssistToolDisplaySetting turns the assist tool display on or off (type: choice).
 	AssistToolDisplaySetting Setting = "assitool_display"
@@ -192,6 +221,8 @@ const (
 )
 
 // Misc settings
+//
+//settings:category misc
 const (
 	// SSIDSetting sets the Wi-Fi SSID (type: string).
 	SSIDSetting Setting = "ssid"
@@ -203,7 +234,7 @@ const (
 	WiFiSetting Setting = "wifi"
 	// BatterySetting shows the battery percentage (type: range).
 	BatterySetting Setting = "battery"
-	// BatterySetting shows the battery voltage, the value need to divided by 10
+	// BatteryVoltage shows the battery voltage, the value need to divided by 10
 	// to get the value in volts (type: range).
 	BatteryVoltage Setting = "battery_voltage"
 	// LEDSetting turns the LED on or off (type: choice).
@@ -229,6 +260,8 @@ const (
 )
 
 // Multiple Camera settings
+//
+//settings:category multiple camera
 const (
 	// MultipleModeSetting sets the multiple camera mode (type: choice, options: single/master/slave).
 	MultipleModeSetting Setting = "multiple_mode"
@@ -237,6 +270,8 @@ const (
 )
 
 // Photo Settings (not supported in E2)
+//
+//settings:category photo
 const (
 	// PhotoSizeSetting sets the photo resolution (type: choice).
 	PhotoSizeSetting Setting = "photosize"
@@ -257,7 +292,3 @@ const (
 	// PhotoSelfIntervalSetting sets the interval for selfie (type: range).
 	PhotoSelfIntervalSetting Setting = "photo_self_interval"
 )
-
-func main() {
-	fmt.Println("Camera settings constants of type 'Setting' with descriptions defined.")
-}
@@ -0,0 +1,553 @@
+// Code generated by settingsgen. DO NOT EDIT.
+
+package settings
+
+var categorySettings = map[Category][]Setting{
+	VideoCategory: {
+		MovFmtSetting,
+		ResolutionSetting,
+		ProjectFPSSetting,
+		RecordFileFormatSetting,
+		RecProxyFileSetting,
+		VideoEncoderSetting,
+		SplitDurationSetting,
+		BitrateLevelSetting,
+		ComposeModeSetting,
+		MovVFRSetting,
+		RecFPSSetting,
+		VideoTLIntervalSetting,
+		EnableVideoTLSetting,
+		RecDurationSetting,
+		LastFileNameSetting,
+	},
+	FocusCategory: {
+		FocusSetting,
+		AFModeSetting,
+		MFDriveSetting,
+		LensZoomSetting,
+		OISModeSetting,
+		AFLockSetting,
+		LensZoomPosSetting,
+		LensFocusPosSetting,
+		LensFocusSpdSetting,
+		CAFSetting,
+		CAFSensSetting,
+		LiveCAFSetting,
+		MFMagSetting,
+		RestoreLensPosSetting,
+	},
+	ExposureCategory: {
+		MeterModeSetting,
+		MaxISOSetting,
+		EVChoiceSetting,
+		ISOSetting,
+		IrisSetting,
+		ShutterAngleSetting,
+		MaxExpShutterAngleSetting,
+		ShutterTimeSetting,
+		MaxExpShutterTimeSetting,
+		ShtOperationSetting,
+		DualISOSetting,
+		AEFreezeSetting,
+		LiveAEFNoSetting,
+		LiveAEISOSetting,
+		LiveAEShutterSetting,
+		LiveAEShutterAngleSetting,
+	},
+	WhiteBalanceCategory: {
+		WBSetting,
+		MWBSetting,
+		TintSetting,
+		WBPrioritySetting,
+		MWBRSetting,
+		MWBGSetting,
+		MWBBSetting,
+	},
+	ImageCategory: {
+		SharpnessSetting,
+		ContrastSetting,
+		SaturationSetting,
+		BrightnessSetting,
+		LUTSetting,
+		LumaLevelSetting,
+		VignetteSetting,
+	},
+	StreamCategory: {
+		SendStreamSetting,
+	},
+	AudioCategory: {
+		PrimaryAudioSetting,
+		AudioChannelSetting,
+		AudioInputGainSetting,
+		AudioOutputGainSetting,
+		AudioPhantomPowerSetting,
+		AINGainTypeSetting,
+	},
+	TimecodeCategory: {
+		TCCountUpSetting,
+		TCHDMIDisplaySetting,
+		TCDropFrameSetting,
+	},
+	AssistToolCategory: {
+		AssistToolDisplaySetting,
+		AssistToolPeakOnOffSetting,
+		AssistToolPeakColorSetting,
+		AssistToolExposureSetting,
+		AssistToolZebraTH1Setting,
+		AssistToolZebraTH2Setting,
+	},
+	MiscCategory: {
+		SSIDSetting,
+		FlickerSetting,
+		VideoSystemSetting,
+		WiFiSetting,
+		BatterySetting,
+		BatteryVoltage,
+		LEDSetting,
+		LCDBacklightSetting,
+		HDMIFormatSetting,
+		HDMIOSDSetting,
+		USBDeviceRoleSetting,
+		UARTRoleSetting,
+		AutoOffSetting,
+		AutoOffLCDSetting,
+		SerialNumberSetting,
+		DesqueezeSetting,
+	},
+	MultipleCameraCategory: {
+		MultipleModeSetting,
+		MultipleIDSetting,
+	},
+	PhotoCategory: {
+		PhotoSizeSetting,
+		PhotoQualitySetting,
+		BurstSetting,
+		MaxExposureSetting,
+		ShootModeSetting,
+		DriveModeSetting,
+		PhotoTLIntervalSetting,
+		PhotoTLNumSetting,
+		PhotoSelfIntervalSetting,
+	},
+}
+
+var registry = map[Setting]Metadata{
+	MovFmtSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the format.",
+	},
+	ResolutionSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the resolution.",
+	},
+	ProjectFPSSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the project frame rate.",
+	},
+	RecordFileFormatSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the file format for recording.",
+	},
+	RecProxyFileSetting: {
+		Kind:        ChoiceKind,
+		Description: "Enables recording of a proxy file.",
+	},
+	VideoEncoderSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the video encoder.",
+	},
+	SplitDurationSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the video record split duration.",
+	},
+	BitrateLevelSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the bitrate level.",
+	},
+	ComposeModeSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the compose mode.",
+	},
+	MovVFRSetting: {
+		Kind:        ChoiceKind,
+		Description: "Enables or disables variable framerate.",
+	},
+	RecFPSSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the playback framerate.",
+	},
+	VideoTLIntervalSetting: {
+		Kind:        RangeKind,
+		Description: "Sets the video timelapse interval.",
+	},
+	EnableVideoTLSetting: {
+		Kind:        ChoiceKind,
+		ReadOnly:    true,
+		Description: "Checks if the camera supports video timelapse.",
+	},
+	RecDurationSetting: {
+		Kind:        RangeKind,
+		ReadOnly:    true,
+		Description: "Sets the recording duration, in seconds.",
+	},
+	LastFileNameSetting: {
+		Kind:        StringKind,
+		ReadOnly:    true,
+		Description: "Queries the last recorded file name.",
+	},
+	FocusSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the focus mode.",
+	},
+	AFModeSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the autofocus mode.",
+	},
+	MFDriveSetting: {
+		Kind:        RangeKind,
+		Description: "Moves the focus plane far/near.",
+	},
+	LensZoomSetting: {
+		Kind:        ChoiceKind,
+		Description: "Controls the lens zoom in/out.",
+	},
+	OISModeSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the lens optical image stabilization mode.",
+	},
+	AFLockSetting: {
+		Kind:        ChoiceKind,
+		Description: "Locks/unlocks autofocus.",
+	},
+	LensZoomPosSetting: {
+		Kind:        RangeKind,
+		Description: "Sets the lens zoom position.",
+	},
+	LensFocusPosSetting: {
+		Kind:        RangeKind,
+		Description: "Sets the lens focus position.",
+	},
+	LensFocusSpdSetting: {
+		Kind:        RangeKind,
+		Description: "Controls the speed of MFDrive/LensFocusPos.",
+	},
+	CAFSetting: {
+		Kind:        ChoiceKind,
+		Description: "Enables or disables continuous autofocus.",
+	},
+	CAFSensSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the sensitivity of continuous autofocus.",
+	},
+	LiveCAFSetting: {
+		Kind:        ChoiceKind,
+		Description: "Turns continuous autofocus on or off.",
+	},
+	MFMagSetting: {
+		Kind:        ChoiceKind,
+		Description: "Magnifies the preview when tuning the manual focus.",
+	},
+	RestoreLensPosSetting: {
+		Kind:        ChoiceKind,
+		Description: "Restores the lens position after reboot.",
+	},
+	MeterModeSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the automatic exposure meter mode.",
+	},
+	MaxISOSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the maximum ISO value.",
+	},
+	EVChoiceSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the exposure value.",
+	},
+	ISOSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the ISO mode.",
+	},
+	IrisSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the aperture size.",
+	},
+	ShutterAngleSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the shutter angle.",
+	},
+	MaxExpShutterAngleSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the maximum video shutter angle.",
+	},
+	ShutterTimeSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the shutter time.",
+	},
+	MaxExpShutterTimeSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the maximum video shutter time.",
+	},
+	ShtOperationSetting: {
+		Kind:        ChoiceKind,
+		Description: "Selects between speed or angle for shutter operation.",
+	},
+	DualISOSetting: {
+		Kind:        ChoiceKind,
+		Description: "Enables or disables dual ISO mode.",
+	},
+	AEFreezeSetting: {
+		Kind:        ChoiceKind,
+		Description: "Locks/unlocks automatic exposure.",
+	},
+	LiveAEFNoSetting: {
+		Kind:        StringKind,
+		ReadOnly:    true,
+		Description: "Shows the live value of the F-number.",
+	},
+	LiveAEISOSetting: {
+		Kind:        StringKind,
+		ReadOnly:    true,
+		Description: "Shows the live value of ISO.",
+	},
+	LiveAEShutterSetting: {
+		Kind:        StringKind,
+		ReadOnly:    true,
+		Description: "Shows the live value of shutter time.",
+	},
+	LiveAEShutterAngleSetting: {
+		Kind:        StringKind,
+		ReadOnly:    true,
+		Description: "Shows the live value of shutter angle.",
+	},
+	WBSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the white balance mode.",
+	},
+	MWBSetting: {
+		Kind:        RangeKind,
+		Description: "Sets the manual white balance in kelvin.",
+	},
+	TintSetting: {
+		Kind:        RangeKind,
+		Description: "Sets the manual white balance tint.",
+	},
+	WBPrioritySetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the white balance priority.",
+	},
+	MWBRSetting: {
+		Kind:        RangeKind,
+		Description: "Sets the manual white balance red gain.",
+	},
+	MWBGSetting: {
+		Kind:        RangeKind,
+		Description: "Sets the manual white balance green gain.",
+	},
+	MWBBSetting: {
+		Kind:        RangeKind,
+		Description: "Sets the manual white balance blue gain.",
+	},
+	SharpnessSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the sharpness level.",
+	},
+	ContrastSetting: {
+		Kind:        RangeKind,
+		Description: "Sets the contrast level.",
+	},
+	SaturationSetting: {
+		Kind:        RangeKind,
+		Description: "Sets the saturation level.",
+	},
+	BrightnessSetting: {
+		Kind:        RangeKind,
+		Description: "Sets the brightness level.",
+	},
+	LUTSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the lookup table.",
+	},
+	LumaLevelSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the luma level.",
+	},
+	VignetteSetting: {
+		Kind:        ChoiceKind,
+		Description: "Applies a vignette effect.",
+	},
+	SendStreamSetting: {
+		Kind:        ChoiceKind,
+		Description: "Selects the stream.",
+	},
+	PrimaryAudioSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the primary audio format.",
+	},
+	AudioChannelSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the audio input channel.",
+	},
+	AudioInputGainSetting: {
+		Kind:        RangeKind,
+		Description: "Sets the audio input gain level.",
+	},
+	AudioOutputGainSetting: {
+		Kind:        RangeKind,
+		Description: "Sets the audio output gain level.",
+	},
+	AudioPhantomPowerSetting: {
+		Kind:        ChoiceKind,
+		Description: "Turns audio phantom power on or off.",
+	},
+	AINGainTypeSetting: {
+		Kind:        ChoiceKind,
+		Description: "Selects the audio gain type.",
+	},
+	TCCountUpSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the timecode count mode.",
+	},
+	TCHDMIDisplaySetting: {
+		Kind:        ChoiceKind,
+		Description: "Displays timecode on HDMI.",
+	},
+	TCDropFrameSetting: {
+		Kind:        ChoiceKind,
+		Description: "Selects timecode drop frame mode.",
+	},
+	AssistToolDisplaySetting: {
+		Kind:        ChoiceKind,
+		Description: "Turns the assist tool display on or off.",
+	},
+	AssistToolPeakOnOffSetting: {
+		Kind:        ChoiceKind,
+		Description: "Turns the peaking assist on or off.",
+	},
+	AssistToolPeakColorSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the peaking assist color.",
+	},
+	AssistToolExposureSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the exposure assist.",
+	},
+	AssistToolZebraTH1Setting: {
+		Kind:        RangeKind,
+		Description: "Sets the Zebra high value threshold.",
+	},
+	AssistToolZebraTH2Setting: {
+		Kind:        RangeKind,
+		Description: "Sets the Zebra low value threshold.",
+	},
+	SSIDSetting: {
+		Kind:        StringKind,
+		Description: "Sets the Wi-Fi SSID.",
+	},
+	FlickerSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets flicker reduction.",
+	},
+	VideoSystemSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the video system.",
+	},
+	WiFiSetting: {
+		Kind:        ChoiceKind,
+		Description: "Turns Wi-Fi on or off.",
+	},
+	BatterySetting: {
+		Kind:        RangeKind,
+		ReadOnly:    true,
+		Description: "Shows the battery percentage.",
+	},
+	BatteryVoltage: {
+		Kind:        RangeKind,
+		ReadOnly:    true,
+		Description: "Shows the battery voltage, the value need to divided by 10 to get the value in volts.",
+	},
+	LEDSetting: {
+		Kind:        ChoiceKind,
+		Description: "Turns the LED on or off.",
+	},
+	LCDBacklightSetting: {
+		Kind:        RangeKind,
+		Description: "Sets the LCD backlight level.",
+	},
+	HDMIFormatSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the HDMI format.",
+	},
+	HDMIOSDSetting: {
+		Kind:        ChoiceKind,
+		Description: "Turns the HDMI on-screen display on or off.",
+	},
+	USBDeviceRoleSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the USB device role.",
+	},
+	UARTRoleSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the UART role.",
+	},
+	AutoOffSetting: {
+		Kind:        ChoiceKind,
+		Description: "Enables or disables camera auto off.",
+	},
+	AutoOffLCDSetting: {
+		Kind:        ChoiceKind,
+		Description: "Enables or disables LCD auto off.",
+	},
+	SerialNumberSetting: {
+		Kind:        StringKind,
+		ReadOnly:    true,
+		Description: "Sets the serial number of the camera.",
+	},
+	DesqueezeSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the desqueeze display ratio.",
+	},
+	MultipleModeSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the multiple camera mode.",
+	},
+	MultipleIDSetting: {
+		Kind:        RangeKind,
+		Description: "Sets the multiple camera ID.",
+	},
+	PhotoSizeSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the photo resolution.",
+	},
+	PhotoQualitySetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the photo quality.",
+	},
+	BurstSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the burst mode.",
+	},
+	MaxExposureSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the maximum exposure time.",
+	},
+	ShootModeSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the AE exposure mode.",
+	},
+	DriveModeSetting: {
+		Kind:        ChoiceKind,
+		Description: "Sets the drive mode.",
+	},
+	PhotoTLIntervalSetting: {
+		Kind:        RangeKind,
+		Description: "Sets the photo timelapse interval.",
+	},
+	PhotoTLNumSetting: {
+		Kind:        RangeKind,
+		Description: "Sets the photo timelapse number.",
+	},
+	PhotoSelfIntervalSetting: {
+		Kind:        RangeKind,
+		Description: "Sets the interval for selfie.",
+	},
+}
@@ -101,6 +101,5 @@ func (c *Camera) sendStreamRequest(ctx context.Context, endpoint string) error {
 		return err
 	}
 
-	fmt.Println(string(body))
-	return decodeBasicRequest(body)
+	return decodeBasicRequest(endpoint, body)
 }
@@ -0,0 +1,140 @@
+package zcam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+)
+
+// ValidationError is returned when a value is rejected by the client-side
+// validation, before being sent to the camera. It wraps ErrInvalidValue,
+// ErrReadOnlySetting or ErrUnexpectedValueType.
+type ValidationError struct {
+	Key   settings.Setting
+	Value string
+	// Setting is the definition of the setting as reported by the camera.
+	Setting *SettingValue
+	Err     error
+}
+
+func (e *ValidationError) Error() string {
+	switch {
+	case errors.Is(e.Err, ErrReadOnlySetting):
+		return fmt.Sprintf("setting %q is read-only", e.Key)
+	case e.Setting != nil && e.Setting.Type == ChoiceSettingType:
+		return fmt.Sprintf("invalid value %q for setting %q, valid choices: %s",
+			e.Value, e.Key, strings.Join(e.Setting.Options, ", "))
+	case e.Setting != nil && e.Setting.Type == RangeSettingType:
+		return fmt.Sprintf("invalid value %q for setting %q, must be between %d and %d with step %d",
+			e.Value, e.Key, e.Setting.Min, e.Setting.Max, e.Setting.Step)
+	default:
+		return fmt.Sprintf("invalid value %q for setting %q: %s", e.Value, e.Key, e.Err)
+	}
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// schemaCache holds the definitions of the settings, used to validate the
+// values. The options of a setting may change along with other settings,
+// such as the frame rates available for a format, so the definitions are
+// dropped when their settings or the ones they depend on are changed.
+type schemaCache struct {
+	mu      sync.Mutex
+	schemas map[settings.Setting]*SettingValue
+}
+
+func (s *schemaCache) get(key settings.Setting) (*SettingValue, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.schemas[key]
+	return v, ok
+}
+
+func (s *schemaCache) set(key settings.Setting, v *SettingValue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.schemas == nil {
+		s.schemas = make(map[settings.Setting]*SettingValue)
+	}
+
+	s.schemas[key] = v
+}
+
+// invalidate drops the definition of the key and of its dependents.
+func (s *schemaCache) invalidate(key settings.Setting) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, k := range withDependents(key) {
+		delete(s.schemas, k)
+	}
+}
+
+func (s *schemaCache) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schemas = nil
+}
+
+// ResetValidationCache drops the setting definitions cached by
+// ValidateSetting, it should be called if the camera options may change,
+// such as after a firmware upgrade.
+func (c *Camera) ResetValidationCache() {
+	c.schemas.reset()
+}
+
+// ValidateSetting checks if the camera would accept the given value, based on
+// the options and limits reported by the camera, the definition of every
+// setting is cached until it, or a setting it depends on, is changed by
+// SetSetting. A *ValidationError is returned if
+// the value is not valid.
+func (c *Camera) ValidateSetting(ctx context.Context, key settings.Setting, value any) error {
+	s, ok := c.schemas.get(key)
+	if !ok {
+		var err error
+		s, err = c.GetSetting(ctx, key)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve %s setting: %w", key, err)
+		}
+
+		c.schemas.set(key, s)
+	}
+
+	str, err := convertToString(value)
+	if err != nil {
+		return &ValidationError{Key: key, Value: fmt.Sprint(value), Setting: s, Err: err}
+	}
+
+	return validateValue(key, s, str)
+}
+
+func validateValue(key settings.Setting, s *SettingValue, value string) error {
+	verr := &ValidationError{Key: key, Value: value, Setting: s, Err: ErrInvalidValue}
+	if s.ReadOnly {
+		verr.Err = ErrReadOnlySetting
+		return verr
+	}
+
+	switch s.Type {
+	case ChoiceSettingType:
+		if !(ChoiceValue{Options: s.Options}).Has(value) {
+			return verr
+		}
+	case RangeSettingType:
+		i, err := strconv.Atoi(value)
+		if err != nil || !(RangeValue{Min: s.Min, Max: s.Max, Step: s.Step}).Contains(i) {
+			return verr
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package zcam
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+	"github.com/mcuadros/go-zcam-e2/zcamtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSetting(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	ctx := context.Background()
+
+	require.NoError(t, cli.ValidateSetting(ctx, settings.FlickerSetting, "60Hz"))
+	require.NoError(t, cli.ValidateSetting(ctx, settings.MWBSetting, 3200))
+
+	err := cli.ValidateSetting(ctx, settings.FlickerSetting, "70Hz")
+	require.ErrorIs(t, err, ErrInvalidValue)
+	require.EqualError(t, err, `invalid value "70Hz" for setting "flicker", valid choices: 50Hz, 60Hz`)
+
+	err = cli.ValidateSetting(ctx, settings.MWBSetting, 3250)
+	require.ErrorIs(t, err, ErrInvalidValue)
+	require.EqualError(t, err, `invalid value "3250" for setting "mwb", must be between 2300 and 10000 with step 100`)
+
+	err = cli.ValidateSetting(ctx, settings.RecDurationSetting, 10)
+	require.ErrorIs(t, err, ErrReadOnlySetting)
+
+	err = cli.ValidateSetting(ctx, settings.FlickerSetting, []string{"60Hz"})
+	require.ErrorIs(t, err, ErrUnexpectedValueType)
+
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	require.Equal(t, settings.FlickerSetting, verr.Key)
+}
+
+func TestValidateSettingCached(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	ctx := context.Background()
+
+	require.NoError(t, cli.ValidateSetting(ctx, settings.FlickerSetting, "60Hz"))
+
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/ctrl/get", StatusCode: 500})
+	require.NoError(t, cli.ValidateSetting(ctx, settings.FlickerSetting, "50Hz"))
+
+	cli.ResetValidationCache()
+	require.Error(t, cli.ValidateSetting(ctx, settings.FlickerSetting, "50Hz"))
+}
+
+func TestValidateSettingInvalidation(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	cli := NewCamera(srv.Listener.Addr().String(), WithValidation())
+	ctx := context.Background()
+
+	require.NoError(t, cli.ValidateSetting(ctx, settings.RecFPSSetting, "29.97"))
+	require.NoError(t, cli.ValidateSetting(ctx, settings.FlickerSetting, "60Hz"))
+
+	// as the camera does, the frame rates available depend on the format
+	srv.Camera.AddSetting(&zcamtest.Setting{
+		Key:     settings.RecFPSSetting,
+		Type:    zcamtest.ChoiceSetting,
+		Value:   "120",
+		Options: []string{"100", "120"},
+	})
+
+	require.NoError(t, cli.SetSetting(ctx, settings.MovFmtSetting, "1080P120"))
+
+	err := cli.ValidateSetting(ctx, settings.RecFPSSetting, "29.97")
+	require.ErrorIs(t, err, ErrInvalidValue)
+	require.NoError(t, cli.ValidateSetting(ctx, settings.RecFPSSetting, "120"))
+
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/ctrl/get", StatusCode: 500})
+	require.NoError(t, cli.ValidateSetting(ctx, settings.FlickerSetting, "50Hz"))
+}
+
+func TestSetSettingValidation(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	cli := NewCamera(srv.Listener.Addr().String(), WithValidation())
+	ctx := context.Background()
+
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/ctrl/set", StatusCode: 500, Count: 1})
+	err := cli.SetSetting(ctx, settings.FlickerSetting, "70Hz")
+	require.ErrorIs(t, err, ErrInvalidValue)
+	require.Equal(t, 0, srv.Faults.Injected())
+	require.Equal(t, "50Hz", srv.Camera.Value(settings.FlickerSetting))
+
+	srv.Faults.Reset()
+	require.NoError(t, cli.SetSetting(ctx, settings.ResolutionSetting, "4K (Low Noise)"))
+	require.Equal(t, "4K (Low Noise)", srv.Camera.Value(settings.ResolutionSetting))
+}
+
+func TestSetSettingUnsupportedType(t *testing.T) {
+	cli := NewCamera(CameraIP)
+
+	err := cli.SetSetting(context.Background(), settings.FlickerSetting, struct{}{})
+	require.ErrorIs(t, err, ErrUnexpectedValueType)
+}
+
+func TestConvertToString(t *testing.T) {
+	for value, expected := range map[any]string{
+		42: "42", int64(7): "7", 29.97: "29.97", float32(0.5): "0.5",
+		"Auto": "Auto", true: "On", false: "Off",
+	} {
+		s, err := convertToString(value)
+		require.NoError(t, err)
+		require.Equal(t, expected, s)
+	}
+
+	require.Equal(t, "CH1%20%26%20CH2", escapeValue("CH1 & CH2"))
+}
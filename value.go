@@ -0,0 +1,196 @@
+package zcam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+)
+
+// ErrUnexpectedValueType is returned by the typed accessors of SettingValue
+// when the value can't be represented as the requested type.
+var ErrUnexpectedValueType = errors.New("unexpected setting value type")
+
+// Int returns the value as an int, numeric strings are parsed.
+func (v *SettingValue) Int() (int, error) {
+	switch value := v.Value.(type) {
+	case float64:
+		if value != math.Trunc(value) {
+			return 0, v.typeError("int")
+		}
+
+		return int(value), nil
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return 0, v.typeError("int")
+		}
+
+		return i, nil
+	default:
+		return 0, v.typeError("int")
+	}
+}
+
+// Float returns the value as a float64, numeric strings are parsed, such as
+// "29.97".
+func (v *SettingValue) Float() (float64, error) {
+	switch value := v.Value.(type) {
+	case float64:
+		return value, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return 0, v.typeError("float")
+		}
+
+		return f, nil
+	default:
+		return 0, v.typeError("float")
+	}
+}
+
+// StringValue returns the value as a string, the numeric values are
+// formatted. It isn't named String since SettingValue implements
+// fmt.Stringer.
+func (v *SettingValue) StringValue() (string, error) {
+	switch value := v.Value.(type) {
+	case string:
+		return value, nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	default:
+		return "", v.typeError("string")
+	}
+}
+
+// Bool returns the value of on/off choices, such as "On"/"Off" or "1"/"0".
+func (v *SettingValue) Bool() (bool, error) {
+	s, err := v.StringValue()
+	if err != nil {
+		return false, v.typeError("bool")
+	}
+
+	switch strings.ToLower(s) {
+	case "on", "1", "true", "enable", "yes":
+		return true, nil
+	case "off", "0", "false", "disable", "no":
+		return false, nil
+	default:
+		return false, v.typeError("bool")
+	}
+}
+
+// Choice returns the value of choice settings along with its options.
+func (v *SettingValue) Choice() (ChoiceValue, error) {
+	if v.Type != ChoiceSettingType {
+		return ChoiceValue{}, v.typeError("choice")
+	}
+
+	s, err := v.StringValue()
+	if err != nil {
+		return ChoiceValue{}, err
+	}
+
+	return ChoiceValue{Value: s, Options: v.Options}, nil
+}
+
+// Range returns the value of range settings along with its limits.
+func (v *SettingValue) Range() (RangeValue, error) {
+	if v.Type != RangeSettingType {
+		return RangeValue{}, v.typeError("range")
+	}
+
+	i, err := v.Int()
+	if err != nil {
+		return RangeValue{}, err
+	}
+
+	return RangeValue{Value: i, Min: v.Min, Max: v.Max, Step: v.Step}, nil
+}
+
+func (v *SettingValue) typeError(expected string) error {
+	return fmt.Errorf("%w: %s value of %q is %T(%v)", ErrUnexpectedValueType, expected, v.Key, v.Value, v.Value)
+}
+
+// ChoiceValue is the value of a choice setting.
+type ChoiceValue struct {
+	Value   string
+	Options []string
+}
+
+// Has returns true if the given option is one of the options.
+func (v ChoiceValue) Has(option string) bool {
+	return v.Index(option) != -1
+}
+
+// Index returns the index of the given option, -1 if is not present.
+func (v ChoiceValue) Index(option string) int {
+	for i, opt := range v.Options {
+		if opt == option {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// RangeValue is the value of a range setting.
+type RangeValue struct {
+	Value int
+	Min   int
+	Max   int
+	Step  int
+}
+
+// Contains returns true if the given value is between Min and Max, and it's
+// a multiple of Step from Min.
+func (v RangeValue) Contains(value int) bool {
+	if value < v.Min || value > v.Max {
+		return false
+	}
+
+	return v.Step <= 0 || (value-v.Min)%v.Step == 0
+}
+
+// TypedValue are the types supported by GetTyped.
+type TypedValue interface {
+	int | float64 | string | bool | ChoiceValue | RangeValue
+}
+
+// GetTyped retrieves a camera setting and returns its value as the given
+// type, an error matching ErrUnexpectedValueType is returned if it doesn't
+// match.
+func GetTyped[T TypedValue](ctx context.Context, c *Camera, key settings.Setting) (T, error) {
+	var zero T
+	v, err := c.GetSetting(ctx, key)
+	if err != nil {
+		return zero, err
+	}
+
+	var value any
+	switch any(zero).(type) {
+	case int:
+		value, err = v.Int()
+	case float64:
+		value, err = v.Float()
+	case string:
+		value, err = v.StringValue()
+	case bool:
+		value, err = v.Bool()
+	case ChoiceValue:
+		value, err = v.Choice()
+	case RangeValue:
+		value, err = v.Range()
+	}
+
+	if err != nil {
+		return zero, err
+	}
+
+	return value.(T), nil
+}
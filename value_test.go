@@ -0,0 +1,118 @@
+package zcam
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+	"github.com/mcuadros/go-zcam-e2/zcamtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettingValueInt(t *testing.T) {
+	v := &SettingValue{Key: "contrast", Value: float64(50)}
+	i, err := v.Int()
+	require.NoError(t, err)
+	require.Equal(t, 50, i)
+
+	v = &SettingValue{Key: "iso", Value: "800"}
+	i, err = v.Int()
+	require.NoError(t, err)
+	require.Equal(t, 800, i)
+
+	for _, value := range []any{"Auto", 2.5, nil} {
+		v = &SettingValue{Key: "iso", Value: value}
+		_, err = v.Int()
+		require.ErrorIs(t, err, ErrUnexpectedValueType)
+	}
+}
+
+func TestSettingValueFloat(t *testing.T) {
+	v := &SettingValue{Key: "project_fps", Value: "29.97"}
+	f, err := v.Float()
+	require.NoError(t, err)
+	require.Equal(t, 29.97, f)
+
+	v = &SettingValue{Key: "project_fps", Value: "Auto"}
+	_, err = v.Float()
+	require.ErrorIs(t, err, ErrUnexpectedValueType)
+}
+
+func TestSettingValueStringValue(t *testing.T) {
+	v := &SettingValue{Key: "contrast", Value: float64(50)}
+	s, err := v.StringValue()
+	require.NoError(t, err)
+	require.Equal(t, "50", s)
+
+	v = &SettingValue{Key: "iso"}
+	_, err = v.StringValue()
+	require.ErrorIs(t, err, ErrUnexpectedValueType)
+	require.NotPanics(t, func() { _ = v.String() })
+}
+
+func TestSettingValueBool(t *testing.T) {
+	for value, expected := range map[string]bool{"On": true, "Off": false, "1": true, "0": false} {
+		v := &SettingValue{Key: "wifi", Value: value}
+		b, err := v.Bool()
+		require.NoError(t, err)
+		require.Equal(t, expected, b)
+	}
+
+	v := &SettingValue{Key: "wifi", Value: "Maybe"}
+	_, err := v.Bool()
+	require.ErrorIs(t, err, ErrUnexpectedValueType)
+}
+
+func TestSettingValueChoiceAndRange(t *testing.T) {
+	v := &SettingValue{Key: "flicker", Type: ChoiceSettingType, Value: "50Hz", Options: []string{"50Hz", "60Hz"}}
+	choice, err := v.Choice()
+	require.NoError(t, err)
+	require.True(t, choice.Has("60Hz"))
+	require.False(t, choice.Has("70Hz"))
+	require.Equal(t, 1, choice.Index("60Hz"))
+
+	_, err = v.Range()
+	require.ErrorIs(t, err, ErrUnexpectedValueType)
+
+	v = &SettingValue{Key: "mwb", Type: RangeSettingType, Value: float64(5600), Min: 2300, Max: 10000, Step: 100}
+	r, err := v.Range()
+	require.NoError(t, err)
+	require.Equal(t, 5600, r.Value)
+	require.True(t, r.Contains(2400))
+	require.False(t, r.Contains(2450))
+	require.False(t, r.Contains(10100))
+
+	_, err = v.Choice()
+	require.ErrorIs(t, err, ErrUnexpectedValueType)
+}
+
+func TestGetTyped(t *testing.T) {
+	srv := zcamtest.NewServer()
+	defer srv.Close()
+
+	cli := NewCamera(srv.Listener.Addr().String())
+	ctx := context.Background()
+
+	contrast, err := GetTyped[int](ctx, cli, settings.ContrastSetting)
+	require.NoError(t, err)
+	require.Equal(t, 50, contrast)
+
+	fps, err := GetTyped[float64](ctx, cli, settings.ProjectFPSSetting)
+	require.NoError(t, err)
+	require.Equal(t, 29.97, fps)
+
+	wifi, err := GetTyped[bool](ctx, cli, settings.WiFiSetting)
+	require.NoError(t, err)
+	require.True(t, wifi)
+
+	iso, err := GetTyped[ChoiceValue](ctx, cli, settings.ISOSetting)
+	require.NoError(t, err)
+	require.Equal(t, "Auto", iso.Value)
+
+	mwb, err := GetTyped[RangeValue](ctx, cli, settings.MWBSetting)
+	require.NoError(t, err)
+	require.Equal(t, 100, mwb.Step)
+
+	_, err = GetTyped[int](ctx, cli, settings.ISOSetting)
+	require.ErrorIs(t, err, ErrUnexpectedValueType)
+}
@@ -0,0 +1,546 @@
+package zcamtest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+)
+
+// DefaultFolder is the DCIM folder where the emulated camera stores the new
+// recordings and stills.
+const DefaultFolder = "100MEDIA"
+
+// Info is the camera information served at the /info endpoint.
+type Info struct {
+	Model  string `json:"model"`
+	Number string `json:"number"`
+	Sw     string `json:"sw"`
+	Hw     string `json:"hw"`
+	Mac    string `json:"mac"`
+	EthIP  string `json:"eth_ip"`
+	SN     string `json:"sn"`
+}
+
+// File is a file stored in the emulated card.
+type File struct {
+	Name        string
+	CreatedAt   time.Time
+	Width       int
+	Height      int
+	Timescale   int
+	PacketCount int
+	// Duration of the clip in milliseconds.
+	Duration int
+	Data     []byte
+}
+
+// StreamConfig is the configuration of one of the encoder streams.
+type StreamConfig struct {
+	Stream        string `json:"streamIndex"`
+	EncoderType   string `json:"encoderType"`
+	Bitwidth      string `json:"bitwidth"`
+	Width         int    `json:"width"`
+	Height        int    `json:"height"`
+	FPS           int    `json:"fps"`
+	SampleUnit    int    `json:"sample_unit"`
+	Bitrate       int    `json:"bitrate"`
+	GopN          int    `json:"gop_n"`
+	Rotation      int    `json:"rotation"`
+	SplitDuration int    `json:"splitDuration"`
+	Status        string `json:"status"`
+}
+
+// Working modes reported by /ctrl/mode?action=query.
+const (
+	ModeRecord           = "rec"
+	ModeRecording        = "rec_ing"
+	ModePlayback         = "pb"
+	ModeStandby          = "standby"
+	ModeTimeLapse        = "rec_tl"
+	ModeTimeLapseRunning = "rec_tl_ing"
+	// ModeStopping is reported while the files of the recording are being
+	// written, see SetStopDelay.
+	ModeStopping = "rec_stopping"
+)
+
+// megabytesPerSecond is the card space consumed by every second of
+// recording, roughly the high bitrate level at 4K.
+const megabytesPerSecond = 15
+
+// Camera is the in-memory state of an emulated Z CAM E2, it implements
+// http.Handler serving the same HTTP API as the real camera.
+type Camera struct {
+	// Now returns the current time of the camera clock, it may be replaced
+	// before serving any request to control the recording durations.
+	Now func() time.Time
+
+	mu          sync.Mutex
+	info        Info
+	mode        string
+	session     bool
+	occupied    bool
+	username    string
+	password    string
+	tokens      map[string]bool
+	recordStart time.Time
+	stopDelay   time.Duration
+	settings    map[settings.Setting]*Setting
+	cardPresent bool
+	fileSystem  string
+	cardTotal   int
+	cardFree    int
+	folders     map[string][]*File
+	temperature int
+	clip        int
+	streams     map[string]*StreamConfig
+	network     map[string]string
+}
+
+// NewCamera returns a camera with the factory settings, in record mode and
+// with an empty card.
+func NewCamera() *Camera {
+	return &Camera{
+		Now: time.Now,
+		info: Info{
+			Model:  "E2",
+			Number: "1",
+			Sw:     "0.98",
+			Hw:     "1",
+			Mac:    "4e:4:b8:2d:78:db",
+			EthIP:  "192.168.9.81",
+			SN:     "329A0010009",
+		},
+		mode:        ModeRecord,
+		settings:    defaultSettings(),
+		cardPresent: true,
+		fileSystem:  "exfat",
+		cardTotal:   122070,
+		cardFree:    122070,
+		folders:     make(map[string][]*File),
+		temperature: 45,
+		streams: map[string]*StreamConfig{
+			"stream0": {
+				Stream: "stream0", EncoderType: "h264", Bitwidth: "8bit",
+				Width: 3840, Height: 2160, FPS: 30, SampleUnit: 1,
+				Bitrate: 120000000, GopN: 30, Status: "idle",
+			},
+			"stream1": {
+				Stream: "stream1", EncoderType: "h264", Bitwidth: "8bit",
+				Width: 1920, Height: 1080, FPS: 30, SampleUnit: 1,
+				Bitrate: 10000000, GopN: 30, Status: "idle",
+			},
+		},
+		network: map[string]string{"mode": "Router"},
+		tokens:  make(map[string]bool),
+	}
+}
+
+// Info returns the camera information.
+func (c *Camera) Info() Info {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.info
+}
+
+// SetInfo replaces the camera information.
+func (c *Camera) SetInfo(info Info) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.info = info
+}
+
+// Mode returns the current working mode, as reported by the camera.
+func (c *Camera) Mode() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.mode
+}
+
+// IsRecording returns true if the camera is recording.
+func (c *Camera) IsRecording() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.isRecording()
+}
+
+func (c *Camera) isRecording() bool {
+	return c.mode == ModeRecording || c.mode == ModeTimeLapseRunning || c.mode == ModeStopping
+}
+
+// SetStopDelay sets how long the camera takes to write the files once a
+// recording is stopped, reporting ModeStopping meanwhile. Zero, the default,
+// writes them immediately.
+func (c *Camera) SetStopDelay(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopDelay = d
+}
+
+// StopRecording stops the recording, as the camera does by itself when the
+// card is full or it overheats.
+func (c *Camera) StopRecording() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.isRecording() && c.mode != ModeStopping {
+		c.stopRecording()
+	}
+}
+
+// HasSession returns true if a control session has been started.
+func (c *Camera) HasSession() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.session
+}
+
+// SetSessionOccupied emulates another client holding the control session,
+// making the session requests fail with 409 Conflict.
+func (c *Camera) SetSessionOccupied(occupied bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.occupied = occupied
+}
+
+// SetCredentials enables the authentication, as newer firmwares do, every
+// request but /info and /login requires the session cookie obtained posting
+// the user and pswd form fields to /login.
+func (c *Camera) SetCredentials(username, password string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.username, c.password = username, password
+}
+
+// ExpireSessions invalidates all the session cookies issued at /login.
+func (c *Camera) ExpireSessions() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tokens = make(map[string]bool)
+}
+
+// Setting returns a copy of the given setting, false if the setting is not
+// supported by the camera.
+func (c *Camera) Setting(key settings.Setting) (*Setting, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.settings[key]
+	if !ok {
+		return nil, false
+	}
+
+	return s.clone(), true
+}
+
+// Value returns the value of the given setting, nil if is not supported.
+func (c *Camera) Value(key settings.Setting) any {
+	s, ok := c.Setting(key)
+	if !ok {
+		return nil
+	}
+
+	return s.Value
+}
+
+// SetValue changes the value of a setting skipping any validation, including
+// the read-only flag, it's meant to prepare the camera state for a test.
+func (c *Camera) SetValue(key settings.Setting, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if s, ok := c.settings[key]; ok {
+		s.Value = value
+	}
+}
+
+// AddSetting adds or replaces a setting definition, allowing to emulate
+// other models or firmware versions.
+func (c *Camera) AddSetting(s *Setting) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.settings[s.Key] = s.clone()
+}
+
+// RemoveSetting makes the given setting unsupported.
+func (c *Camera) RemoveSetting(key settings.Setting) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.settings, key)
+}
+
+// SetCardPresent inserts or removes the card, removing it drops all the
+// files.
+func (c *Camera) SetCardPresent(present bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cardPresent = present
+	if !present {
+		c.folders = make(map[string][]*File)
+	}
+}
+
+// SetCardFreeSpace sets the free space of the card in megabytes.
+func (c *Camera) SetCardFreeSpace(mb int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cardFree = mb
+}
+
+// SetTemperature sets the temperature reported by the camera in celsius.
+func (c *Camera) SetTemperature(celsius int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.temperature = celsius
+}
+
+// AddFile stores a file in the given folder of the card, replacing the file
+// with the same name, if any.
+func (c *Camera) AddFile(folder string, f *File) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.addFile(folder, f)
+}
+
+func (c *Camera) addFile(folder string, f *File) {
+	for i, existing := range c.folders[folder] {
+		if existing.Name == f.Name {
+			c.folders[folder][i] = f
+			return
+		}
+	}
+
+	c.folders[folder] = append(c.folders[folder], f)
+}
+
+// File returns the file with the given name from the given folder.
+func (c *Camera) File(folder, name string) (*File, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.file(folder, name)
+}
+
+func (c *Camera) file(folder, name string) (*File, bool) {
+	for _, f := range c.folders[folder] {
+		if f.Name == name {
+			return f, true
+		}
+	}
+
+	return nil, false
+}
+
+// Files returns the files stored in the given folder.
+func (c *Camera) Files(folder string) []*File {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*File(nil), c.folders[folder]...)
+}
+
+// Folders returns the sorted list of folders in the card.
+func (c *Camera) Folders() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.folderNames()
+}
+
+func (c *Camera) folderNames() []string {
+	var names []string
+	for name := range c.folders {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func (c *Camera) removeFile(folder, name string) bool {
+	files := c.folders[folder]
+	for i, f := range files {
+		if f.Name == name {
+			c.folders[folder] = append(files[:i:i], files[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Camera) startRecording() {
+	c.recordStart = c.Now()
+	if c.mode == ModeTimeLapse {
+		c.mode = ModeTimeLapseRunning
+	} else {
+		c.mode = ModeRecording
+	}
+}
+
+// stopRecording stops the current recording, writing its files after the
+// stop delay, if any.
+func (c *Camera) stopRecording() {
+	mode := ModeRecord
+	if c.mode == ModeTimeLapseRunning {
+		mode = ModeTimeLapse
+	}
+
+	end := c.Now()
+	if c.stopDelay <= 0 {
+		c.writeRecording(end, mode)
+		return
+	}
+
+	c.mode = ModeStopping
+	time.AfterFunc(c.stopDelay, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.writeRecording(end, mode)
+	})
+}
+
+// writeRecording writes the files of the recording ended at the given time,
+// one per split segment, plus the proxy files if enabled, and switches back
+// to the given mode.
+func (c *Camera) writeRecording(end time.Time, mode string) {
+	c.mode = mode
+
+	start := c.recordStart
+	total := end.Sub(start)
+	if total < time.Second {
+		total = time.Second
+	}
+
+	c.clip++
+	c.cardFree -= int(total.Seconds()) * megabytesPerSecond
+	if c.cardFree < 0 {
+		c.cardFree = 0
+	}
+
+	segment := c.splitDuration()
+	if segment <= 0 {
+		segment = total
+	}
+
+	ext := c.stringValue(settings.RecordFileFormatSetting)
+	proxy := c.stringValue(settings.RecProxyFileSetting) == "On"
+	width, height := c.resolution()
+	fps := c.fps()
+
+	var last string
+	segments := int(math.Ceil(float64(total) / float64(segment)))
+	for i := 0; i < segments; i++ {
+		offset := time.Duration(i) * segment
+		d := total - offset
+		if d > segment {
+			d = segment
+		}
+
+		base := fmt.Sprintf("ZCAM%04d_%04d_%s", c.clip, i, start.Format("200601021504"))
+		last = base + "." + ext
+		c.addFile(DefaultFolder, newClipFile(last, start.Add(offset), d, width, height, fps))
+
+		if proxy {
+			c.addFile(DefaultFolder, newClipFile(base+"_proxy."+ext, start.Add(offset), d, 1920, 1080, fps))
+		}
+	}
+
+	c.settings[settings.LastFileNameSetting].Value = "/DCIM/" + DefaultFolder + "/" + last
+}
+
+func (c *Camera) captureStill() {
+	c.clip++
+
+	now := c.Now()
+	width, height := c.resolution()
+	name := fmt.Sprintf("ZCAM%04d_0000_%s.JPG", c.clip, now.Format("200601021504"))
+	c.addFile(DefaultFolder, &File{
+		Name:      name,
+		CreatedAt: now,
+		Width:     width,
+		Height:    height,
+		Data:      pattern(4096),
+	})
+
+	c.settings[settings.LastFileNameSetting].Value = "/DCIM/" + DefaultFolder + "/" + name
+}
+
+func (c *Camera) format() {
+	c.folders = make(map[string][]*File)
+	c.cardFree = c.cardTotal
+}
+
+func (c *Camera) remainingMinutes() int {
+	return c.cardFree / megabytesPerSecond / 60
+}
+
+func (c *Camera) stringValue(key settings.Setting) string {
+	s, ok := c.settings[key]
+	if !ok {
+		return ""
+	}
+
+	v, _ := s.Value.(string)
+	return v
+}
+
+func (c *Camera) splitDuration() time.Duration {
+	mins, err := strconv.Atoi(c.stringValue(settings.SplitDurationSetting))
+	if err != nil {
+		return 0
+	}
+
+	return time.Duration(mins) * time.Minute
+}
+
+func (c *Camera) resolution() (int, int) {
+	switch c.stringValue(settings.ResolutionSetting) {
+	case "C4K":
+		return 4096, 2160
+	case "1920x1080":
+		return 1920, 1080
+	default:
+		return 3840, 2160
+	}
+}
+
+func (c *Camera) fps() float64 {
+	fps, err := strconv.ParseFloat(c.stringValue(settings.ProjectFPSSetting), 64)
+	if err != nil {
+		return 30
+	}
+
+	return fps
+}
+
+// newClipFile returns a video file, the content is 1KiB per second.
+func newClipFile(name string, created time.Time, d time.Duration, width, height int, fps float64) *File {
+	return &File{
+		Name:        name,
+		CreatedAt:   created,
+		Width:       width,
+		Height:      height,
+		Timescale:   int(math.Round(fps * 1000)),
+		PacketCount: int(d.Seconds() * fps),
+		Duration:    int(d / time.Millisecond),
+		Data:        pattern(int(math.Ceil(d.Seconds())) * 1024),
+	}
+}
+
+// NewClip returns a video file of the given duration at 4K and 29.97fps, to
+// be used with Camera.AddFile.
+func NewClip(name string, created time.Time, d time.Duration) *File {
+	return newClipFile(name, created, d, 3840, 2160, 29.97)
+}
+
+func pattern(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+
+	return data
+}
@@ -0,0 +1,255 @@
+package zcamtest
+
+import (
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fault describes a misbehaviour injected in the responses of the requests
+// matching the Endpoint. Once the latency is applied, only one of the failure
+// modes takes place, in the order they are declared.
+type Fault struct {
+	// Endpoint is matched as a prefix of the request URI, such as "/ctrl/set"
+	// or "/ctrl/rec?action=start". Empty matches every request.
+	Endpoint string
+	// Probability of applying the fault to a matching request, between 0 and
+	// 1. Zero means always.
+	Probability float64
+	// Count limits the number of times the fault is applied, zero means no
+	// limit.
+	Count int
+
+	// Latency delays the response, plus a random duration up to Jitter.
+	Latency time.Duration
+	Jitter  time.Duration
+
+	// Drop closes the connection without sending any response.
+	Drop bool
+	// StatusCode responds with the given HTTP status code.
+	StatusCode int
+	// Code responds with a JSON body with the given code and Desc, as the
+	// camera does on a failed command.
+	Code int
+	Desc string
+	// Truncate cuts the response body by half, leading to invalid JSON.
+	Truncate bool
+	// Interrupt closes the connection after sending InterruptAfter bytes of
+	// the response body, if Stall is set the connection stalls for the given
+	// duration, or until the client gives up, before being closed.
+	Interrupt      bool
+	InterruptAfter int64
+	Stall          time.Duration
+	// Throttle sends the headers at once and then delays every KiB of the
+	// response body by the given duration, emulating a slow transfer.
+	Throttle time.Duration
+
+	hits int
+}
+
+func (f *Fault) matches(r *http.Request) bool {
+	if f.Count > 0 && f.hits >= f.Count {
+		return false
+	}
+
+	return strings.HasPrefix(r.URL.RequestURI(), f.Endpoint)
+}
+
+// Faults is an http.Handler injecting faults in the responses of the wrapped
+// handler. With no faults added, the requests are served untouched.
+type Faults struct {
+	handler http.Handler
+
+	mu       sync.Mutex
+	rand     *rand.Rand
+	faults   []*Fault
+	injected int
+}
+
+// NewFaults returns a new Faults wrapping the given handler, the seed is used
+// for the random decisions, making them reproducible.
+func NewFaults(h http.Handler, seed int64) *Faults {
+	return &Faults{
+		handler: h,
+		rand:    rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Add adds a new fault, the first matching fault is the one applied.
+func (f *Faults) Add(fault Fault) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fault.hits = 0
+	f.faults = append(f.faults, &fault)
+}
+
+// Reset removes all the faults.
+func (f *Faults) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.faults = nil
+}
+
+// Injected returns the number of responses altered by a fault.
+func (f *Faults) Injected() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.injected
+}
+
+func (f *Faults) pick(r *http.Request) (Fault, time.Duration, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, fault := range f.faults {
+		if !fault.matches(r) {
+			continue
+		}
+
+		if fault.Probability > 0 && f.rand.Float64() >= fault.Probability {
+			continue
+		}
+
+		fault.hits++
+		f.injected++
+
+		latency := fault.Latency
+		if fault.Jitter > 0 {
+			latency += time.Duration(f.rand.Int63n(int64(fault.Jitter)))
+		}
+
+		return *fault, latency, true
+	}
+
+	return Fault{}, 0, false
+}
+
+// ServeHTTP implements http.Handler.
+func (f *Faults) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fault, latency, ok := f.pick(r)
+	if !ok {
+		f.handler.ServeHTTP(w, r)
+		return
+	}
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	switch {
+	case fault.Drop:
+		// aborting the handler makes the server close the connection
+		panic(http.ErrAbortHandler)
+	case fault.StatusCode != 0:
+		w.WriteHeader(fault.StatusCode)
+	case fault.Code != 0:
+		writeResponse(w, fault.Code, fault.Desc, "")
+	case fault.Truncate:
+		rec := httptest.NewRecorder()
+		f.handler.ServeHTTP(rec, r)
+
+		body := rec.Body.Bytes()
+		for k, v := range rec.Header() {
+			w.Header()[k] = v
+		}
+
+		w.Header().Del("Content-Length")
+		w.WriteHeader(rec.Code)
+		w.Write(body[:len(body)/2])
+	case fault.Interrupt:
+		f.handler.ServeHTTP(&interruptWriter{
+			ResponseWriter: w,
+			r:              r,
+			remaining:      fault.InterruptAfter,
+			stall:          fault.Stall,
+		}, r)
+	case fault.Throttle > 0:
+		f.handler.ServeHTTP(&throttleWriter{ResponseWriter: w, r: r, delay: fault.Throttle}, r)
+	default:
+		f.handler.ServeHTTP(w, r)
+	}
+}
+
+// throttleWriter flushes the headers and writes the body by KiB, waiting the
+// delay before every one of them.
+type throttleWriter struct {
+	http.ResponseWriter
+	r     *http.Request
+	delay time.Duration
+}
+
+func (w *throttleWriter) WriteHeader(code int) {
+	w.ResponseWriter.WriteHeader(code)
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *throttleWriter) Write(p []byte) (int, error) {
+	var n int
+	for len(p) > 0 {
+		select {
+		case <-time.After(w.delay):
+		case <-w.r.Context().Done():
+			return n, w.r.Context().Err()
+		}
+
+		chunk := p
+		if len(chunk) > 1024 {
+			chunk = chunk[:1024]
+		}
+
+		written, err := w.ResponseWriter.Write(chunk)
+		n += written
+		if err != nil {
+			return n, err
+		}
+
+		if f, ok := w.ResponseWriter.(http.Flusher); ok {
+			f.Flush()
+		}
+
+		p = p[len(chunk):]
+	}
+
+	return n, nil
+}
+
+// interruptWriter writes up to remaining bytes, then it stalls and closes the
+// connection.
+type interruptWriter struct {
+	http.ResponseWriter
+	r         *http.Request
+	remaining int64
+	stall     time.Duration
+}
+
+func (w *interruptWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) <= w.remaining {
+		w.remaining -= int64(len(p))
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.ResponseWriter.Write(p[:w.remaining])
+	w.remaining = 0
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+
+	if w.stall > 0 {
+		select {
+		case <-time.After(w.stall):
+		case <-w.r.Context().Done():
+		}
+	}
+
+	panic(http.ErrAbortHandler)
+}
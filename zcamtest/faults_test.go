@@ -0,0 +1,108 @@
+package zcamtest_test
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+	"github.com/mcuadros/go-zcam-e2/zcamtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaultsStatusCode(t *testing.T) {
+	srv, cli := newCamera(t)
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/info", StatusCode: http.StatusServiceUnavailable, Count: 1})
+
+	_, err := cli.GetCameraInfo(context.Background())
+	require.Error(t, err)
+
+	_, err = cli.GetCameraInfo(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, srv.Faults.Injected())
+}
+
+func TestFaultsTruncate(t *testing.T) {
+	srv, cli := newCamera(t)
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/info", Truncate: true})
+
+	_, err := cli.GetCameraInfo(context.Background())
+	require.ErrorContains(t, err, "error decoding JSON")
+}
+
+func TestFaultsLatency(t *testing.T) {
+	srv, cli := newCamera(t)
+	srv.Faults.Add(zcamtest.Fault{Latency: time.Second})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := cli.GetCameraInfo(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestFaultsSetSettings(t *testing.T) {
+	srv, cli := newCamera(t)
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/ctrl/set?iso", Code: -1, Desc: "busy"})
+
+	err := cli.SetSettings(context.Background(), map[settings.Setting]any{
+		settings.ISOSetting: "800",
+	})
+
+	require.ErrorContains(t, err, "error setting iso")
+	require.Equal(t, "Auto", srv.Camera.Value(settings.ISOSetting))
+}
+
+func TestFaultsVideoRecordDrop(t *testing.T) {
+	srv, cli := newCamera(t)
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/ctrl/rec?action=stop", Drop: true})
+
+	_, err := cli.VideoRecord(context.Background(), 10*time.Millisecond)
+	require.ErrorContains(t, err, "error stopping video")
+	require.True(t, srv.Camera.IsRecording())
+}
+
+func TestFaultsDownloadInterrupt(t *testing.T) {
+	srv, cli := newCamera(t)
+	srv.Camera.AddFile(zcamtest.DefaultFolder, zcamtest.NewClip("clip.MOV", time.Now(), 10*time.Second))
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/DCIM/", Interrupt: true, InterruptAfter: 512})
+
+	files, err := cli.ListFiles(context.Background(), zcamtest.DefaultFolder)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	_, err = files[0].Download(context.Background(), "", filepath.Join(t.TempDir(), "clip.MOV"))
+	require.Error(t, err)
+}
+
+func TestFaultsDownloadStall(t *testing.T) {
+	srv, cli := newCamera(t)
+	srv.Camera.AddFile(zcamtest.DefaultFolder, zcamtest.NewClip("clip.MOV", time.Now(), 10*time.Second))
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/DCIM/100MEDIA/clip.MOV", Interrupt: true, Stall: time.Minute})
+
+	files, err := cli.ListFiles(context.Background(), zcamtest.DefaultFolder)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = files[0].Download(ctx, "", filepath.Join(t.TempDir(), "clip.MOV"))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestFaultsProbability(t *testing.T) {
+	srv, cli := newCamera(t)
+	srv.Faults.Add(zcamtest.Fault{Endpoint: "/ctrl/temperature", Probability: 0.5, Code: -1})
+
+	var failed int
+	for i := 0; i < 100; i++ {
+		if _, err := cli.QueryTemperature(context.Background()); err != nil {
+			failed++
+		}
+	}
+
+	require.Equal(t, srv.Faults.Injected(), failed)
+	require.InDelta(t, 50, failed, 20)
+}
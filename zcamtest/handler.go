@@ -0,0 +1,465 @@
+package zcamtest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mcuadros/go-zcam-e2/settings"
+)
+
+const rootFolder = "/DCIM"
+
+type response struct {
+	Code int    `json:"code"`
+	Desc string `json:"desc"`
+	Msg  string `json:"msg"`
+}
+
+// SessionCookie is the name of the cookie set at /login.
+const SessionCookie = "session"
+
+// ServeHTTP implements http.Handler, serving the camera HTTP API.
+func (c *Camera) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/login":
+		c.serveLogin(w, r)
+		return
+	case r.URL.Path != "/info" && !c.authorized(r):
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if r.URL.Path == rootFolder || strings.HasPrefix(r.URL.Path, rootFolder+"/") {
+		c.serveFiles(w, r)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	q := r.URL.Query()
+	switch r.URL.Path {
+	case "/info":
+		writeJSON(w, c.info)
+	case "/datetime", "/ctrl/shutdown", "/ctrl/reboot", "/ctrl/af":
+		writeResponse(w, 0, "", "")
+	case "/ctrl/session":
+		if c.occupied {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+
+		c.session = q.Get("action") != "quit"
+		writeResponse(w, 0, "", "")
+	case "/ctrl/mode":
+		c.serveMode(w, q.Get("action"))
+	case "/ctrl/get":
+		c.serveGet(w, settings.Setting(q.Get("k")))
+	case "/ctrl/set":
+		c.serveSet(w, r)
+	case "/ctrl/rec":
+		c.serveRecord(w, q.Get("action"))
+	case "/ctrl/still":
+		c.serveStill(w)
+	case "/ctrl/temperature":
+		writeResponse(w, 0, "", strconv.Itoa(c.temperature))
+	case "/ctrl/card":
+		c.serveCard(w, q.Get("action"))
+	case "/ctrl/network":
+		c.serveNetwork(w, r)
+	case "/ctrl/stream_setting":
+		c.serveStreamSetting(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (c *Camera) serveLogin(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.username == "" || r.FormValue("user") != c.username || r.FormValue("pswd") != c.password {
+		writeFailure(w)
+		return
+	}
+
+	token := make([]byte, 16)
+	rand.Read(token)
+
+	value := hex.EncodeToString(token)
+	c.tokens[value] = true
+	http.SetCookie(w, &http.Cookie{Name: SessionCookie, Value: value, Path: "/"})
+	writeResponse(w, 0, "", "")
+}
+
+func (c *Camera) authorized(r *http.Request) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.username == "" {
+		return true
+	}
+
+	cookie, err := r.Cookie(SessionCookie)
+	return err == nil && c.tokens[cookie.Value]
+}
+
+func (c *Camera) serveMode(w http.ResponseWriter, action string) {
+	switch action {
+	case "query":
+		writeResponse(w, 0, "", c.mode)
+		return
+	case ModeRecord, ModePlayback, ModeStandby, ModeTimeLapse:
+		if c.isRecording() {
+			writeFailure(w)
+			return
+		}
+
+		c.mode = action
+	case "exit_standby":
+		if c.mode == ModeStandby {
+			c.mode = ModeRecord
+		}
+	default:
+		writeFailure(w)
+		return
+	}
+
+	writeResponse(w, 0, "", "")
+}
+
+func (c *Camera) serveGet(w http.ResponseWriter, key settings.Setting) {
+	s, ok := c.settings[key]
+	if !ok {
+		writeFailure(w)
+		return
+	}
+
+	ro := 0
+	if s.ReadOnly {
+		ro = 1
+	}
+
+	value := s.Value
+	if key == settings.RecDurationSetting && c.isRecording() {
+		value = int(c.Now().Sub(c.recordStart).Seconds())
+	}
+
+	out := map[string]any{
+		"code":  0,
+		"desc":  "",
+		"key":   s.Key,
+		"type":  s.Type,
+		"ro":    ro,
+		"value": value,
+	}
+
+	switch s.Type {
+	case ChoiceSetting:
+		out["opts"] = s.Options
+	case RangeSetting:
+		out["min"] = s.Min
+		out["max"] = s.Max
+		out["step"] = s.Step
+	}
+
+	writeJSON(w, out)
+}
+
+func (c *Camera) serveSet(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if len(q) != 1 {
+		writeFailure(w)
+		return
+	}
+
+	for k := range q {
+		if !c.set(settings.Setting(k), q.Get(k)) {
+			writeFailure(w)
+			return
+		}
+
+		writeResponse(w, 0, "", "")
+	}
+}
+
+// set changes the value of the setting, returning false if it's rejected.
+func (c *Camera) set(key settings.Setting, value string) bool {
+	s, ok := c.settings[key]
+	if !ok {
+		return false
+	}
+
+	if s.ReadOnly {
+		return false
+	}
+
+	switch s.Type {
+	case ChoiceSetting:
+		valid := false
+		for _, opt := range s.Options {
+			valid = valid || opt == value
+		}
+
+		if !valid {
+			return false
+		}
+
+		// as the E2 does, changing the format resets the variable frame
+		// rate, so the order in which the settings are applied matters.
+		if s.Value != value && (key == settings.MovFmtSetting || key == settings.ResolutionSetting) {
+			if vfr, ok := c.settings[settings.MovVFRSetting]; ok {
+				vfr.Value = "Off"
+			}
+		}
+
+		s.Value = value
+	case RangeSetting:
+		v, err := strconv.Atoi(value)
+		if err != nil || v < s.Min || v > s.Max || (s.Step > 0 && (v-s.Min)%s.Step != 0) {
+			return false
+		}
+
+		s.Value = v
+	default:
+		s.Value = value
+	}
+
+	return true
+}
+
+func (c *Camera) serveRecord(w http.ResponseWriter, action string) {
+	switch action {
+	case "start":
+		switch {
+		case !c.cardPresent:
+			writeFailure(w)
+		case c.isRecording():
+			writeFailure(w)
+		case c.mode != ModeRecord && c.mode != ModeTimeLapse:
+			writeFailure(w)
+		default:
+			c.startRecording()
+			writeResponse(w, 0, "", "")
+		}
+	case "stop":
+		switch {
+		case c.mode == ModeStopping:
+			writeFailure(w)
+			return
+		case !c.isRecording():
+			writeFailure(w)
+			return
+		}
+
+		c.stopRecording()
+		writeResponse(w, 0, "", "")
+	case "remain":
+		if !c.cardPresent {
+			writeFailure(w)
+			return
+		}
+
+		writeResponse(w, 0, "", strconv.Itoa(c.remainingMinutes()))
+	default:
+		writeFailure(w)
+	}
+}
+
+func (c *Camera) serveStill(w http.ResponseWriter) {
+	if !c.cardPresent {
+		writeFailure(w)
+		return
+	}
+
+	c.captureStill()
+	writeResponse(w, 0, "", "")
+}
+
+func (c *Camera) serveCard(w http.ResponseWriter, action string) {
+	if !c.cardPresent {
+		writeFailure(w)
+		return
+	}
+
+	switch action {
+	case "present":
+		writeResponse(w, 0, "", "")
+	case "format", "fat32", "exfat":
+		if c.isRecording() {
+			writeFailure(w)
+			return
+		}
+
+		if action != "format" {
+			c.fileSystem = action
+		}
+
+		c.format()
+		writeResponse(w, 0, "", "")
+	case "query_free":
+		writeResponse(w, 0, "", strconv.Itoa(c.cardFree))
+	case "query_total":
+		writeResponse(w, 0, "", strconv.Itoa(c.cardTotal))
+	default:
+		writeFailure(w)
+	}
+}
+
+func (c *Camera) serveNetwork(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("action") == "set" {
+		c.network = map[string]string{"mode": q.Get("mode")}
+		if q.Get("mode") == "Static" {
+			c.network["ip"] = q.Get("ipaddr")
+			c.network["netmask"] = q.Get("netmask")
+			c.network["gateway"] = q.Get("gateway")
+		}
+	}
+
+	out := map[string]any{"code": 0, "desc": ""}
+	for k, v := range c.network {
+		out[k] = v
+	}
+
+	writeJSON(w, out)
+}
+
+func (c *Camera) serveStreamSetting(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	cfg, ok := c.streams[q.Get("index")]
+	if !ok {
+		writeFailure(w)
+		return
+	}
+
+	if q.Get("action") == "query" {
+		writeJSON(w, map[string]any{
+			"code":          0,
+			"desc":          "",
+			"streamIndex":   cfg.Stream,
+			"encoderType":   cfg.EncoderType,
+			"bitwidth":      cfg.Bitwidth,
+			"width":         cfg.Width,
+			"height":        cfg.Height,
+			"fps":           cfg.FPS,
+			"sample_unit":   cfg.SampleUnit,
+			"bitrate":       cfg.Bitrate,
+			"gop_n":         cfg.GopN,
+			"rotation":      cfg.Rotation,
+			"splitDuration": cfg.SplitDuration,
+			"status":        cfg.Status,
+		})
+		return
+	}
+
+	for k := range q {
+		v := q.Get(k)
+		n, _ := strconv.Atoi(v)
+		switch k {
+		case "width":
+			cfg.Width = n
+		case "height":
+			cfg.Height = n
+		case "bitrate":
+			cfg.Bitrate = n
+		case "fps":
+			cfg.FPS = n
+		case "venc":
+			cfg.EncoderType = v
+		case "bitwidth":
+			cfg.Bitwidth = v
+		}
+	}
+
+	writeResponse(w, 0, "", "")
+}
+
+func (c *Camera) serveFiles(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.cardPresent {
+		writeFailure(w)
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, rootFolder), "/")
+	if path == "" {
+		writeJSON(w, map[string]any{"code": 0, "desc": "", "files": c.folderNames()})
+		return
+	}
+
+	folder, name, isFile := strings.Cut(path, "/")
+	if !isFile {
+		files, ok := c.folders[folder]
+		if !ok {
+			writeFailure(w)
+			return
+		}
+
+		names := make([]string, 0, len(files))
+		for _, f := range files {
+			names = append(names, f.Name)
+		}
+
+		writeJSON(w, map[string]any{"code": 0, "desc": "", "files": names})
+		return
+	}
+
+	f, ok := c.file(folder, name)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.URL.Query().Get("act") {
+	case "":
+		// the content is served without the lock, files are never modified
+		// once written.
+		c.mu.Unlock()
+		defer c.mu.Lock()
+		http.ServeContent(w, r, f.Name, f.CreatedAt, bytes.NewReader(f.Data))
+	case "rm":
+		c.removeFile(folder, name)
+		writeResponse(w, 0, "", "")
+	case "thm", "scr":
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write(pattern(512))
+	case "ct":
+		writeResponse(w, 0, "", strconv.FormatInt(f.CreatedAt.Unix(), 10))
+	case "info":
+		writeJSON(w, map[string]any{
+			"code": 0,
+			"desc": "",
+			"msg":  "",
+			"w":    f.Width,
+			"h":    f.Height,
+			"vts":  f.Timescale,
+			"vcnt": f.PacketCount,
+			"dur":  f.Duration,
+		})
+	default:
+		writeFailure(w)
+	}
+}
+
+// writeFailure writes a failed command, as the camera does: a non-zero code
+// without any description of the cause.
+func writeFailure(w http.ResponseWriter) {
+	writeResponse(w, -1, "", "")
+}
+
+func writeResponse(w http.ResponseWriter, code int, desc, msg string) {
+	writeJSON(w, response{Code: code, Desc: desc, Msg: msg})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
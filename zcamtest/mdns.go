@@ -0,0 +1,87 @@
+package zcamtest
+
+import (
+	"net"
+	"strings"
+
+	"github.com/mcuadros/go-zcam-e2/internal/mdns"
+)
+
+// MDNSResponder is a fake mDNS responder advertising a camera as a DNS-SD
+// service instance. It listens on the loopback interface instead of the
+// multicast group, so the queries must be sent to its address directly.
+type MDNSResponder struct {
+	conn    *net.UDPConn
+	records []mdns.Record
+	service string
+}
+
+// NewMDNSResponder starts a responder for the given service, such as
+// "_http._tcp.local.", announcing an instance reachable at the given TCP
+// address, usually the Listener address of a Server.
+func NewMDNSResponder(service, instance string, addr *net.TCPAddr) (*MDNSResponder, error) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		return nil, err
+	}
+
+	service = strings.TrimSuffix(service, ".") + "."
+	name := instance + "." + service
+	host := strings.ToLower(instance) + ".local."
+
+	ipType := mdns.TypeA
+	if addr.IP.To4() == nil {
+		ipType = mdns.TypeAAAA
+	}
+
+	r := &MDNSResponder{
+		conn:    conn,
+		service: service,
+		records: []mdns.Record{
+			{Name: service, Type: mdns.TypePTR, TTL: 120, Target: name},
+			{Name: name, Type: mdns.TypeSRV, TTL: 120, Target: host, Port: uint16(addr.Port)},
+			{Name: host, Type: ipType, TTL: 120, IP: addr.IP},
+		},
+	}
+
+	go r.serve()
+	return r, nil
+}
+
+// Addr returns the UDP address where the responder is listening.
+func (r *MDNSResponder) Addr() string {
+	return r.conn.LocalAddr().String()
+}
+
+// Close stops the responder.
+func (r *MDNSResponder) Close() error {
+	return r.conn.Close()
+}
+
+func (r *MDNSResponder) serve() {
+	buf := make([]byte, 1500)
+	for {
+		n, from, err := r.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+
+		m, err := mdns.Parse(buf[:n])
+		if err != nil || m.Response {
+			continue
+		}
+
+		for _, q := range m.Questions {
+			if q.Type != mdns.TypePTR || !strings.EqualFold(q.Name, r.service) {
+				continue
+			}
+
+			msg, err := mdns.Response(r.records)
+			if err != nil {
+				continue
+			}
+
+			r.conn.WriteToUDP(msg, from)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+// Package zcamtest provides an in-process Z CAM E2 emulator, serving the same
+// HTTP API as the camera from an in-memory state, for offline testing.
+//
+//	srv := zcamtest.NewServer()
+//	defer srv.Close()
+//
+//	cli := zcam.NewCamera(srv.Listener.Addr().String())
+package zcamtest
+
+import (
+	"net/http/httptest"
+)
+
+// Server is an HTTP server emulating a Z CAM E2, listening on a system-chosen
+// port on the local loopback interface.
+type Server struct {
+	*httptest.Server
+	// Camera is the emulated camera state, it can be inspected and changed
+	// while the server is running.
+	Camera *Camera
+	// Faults injects faults in the responses, by default none.
+	Faults *Faults
+}
+
+// NewServer starts and returns a new Server emulating a camera with the
+// factory settings. The caller should call Close when finished, to shut it
+// down.
+func NewServer() *Server {
+	s := NewUnstartedServer()
+	s.Start()
+	return s
+}
+
+// NewUnstartedServer returns a new Server but doesn't start it, allowing to
+// change its configuration before calling Start.
+func NewUnstartedServer() *Server {
+	c := NewCamera()
+	f := NewFaults(c, 1)
+	return &Server{
+		Server: httptest.NewUnstartedServer(f),
+		Camera: c,
+		Faults: f,
+	}
+}
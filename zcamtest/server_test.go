@@ -0,0 +1,100 @@
+package zcamtest_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/mcuadros/go-zcam-e2"
+	"github.com/mcuadros/go-zcam-e2/settings"
+	"github.com/mcuadros/go-zcam-e2/zcamtest"
+	"github.com/stretchr/testify/require"
+)
+
+func newCamera(t *testing.T) (*zcamtest.Server, *zcam.Camera) {
+	srv := zcamtest.NewServer()
+	t.Cleanup(srv.Close)
+
+	return srv, zcam.NewCamera(srv.Listener.Addr().String())
+}
+
+func TestServerGetCameraInfo(t *testing.T) {
+	srv, cli := newCamera(t)
+
+	info, err := cli.GetCameraInfo(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, srv.Camera.Info().SN, info.SN)
+	require.Equal(t, "E2", info.Model)
+}
+
+func TestServerSetSetting(t *testing.T) {
+	srv, cli := newCamera(t)
+
+	ctx := context.Background()
+	require.NoError(t, cli.SetSetting(ctx, settings.ContrastSetting, 60))
+	require.Equal(t, 60, srv.Camera.Value(settings.ContrastSetting))
+
+	require.Error(t, cli.SetSetting(ctx, settings.ContrastSetting, 101))
+	require.Error(t, cli.SetSetting(ctx, settings.FlickerSetting, "70Hz"))
+	require.Error(t, cli.SetSetting(ctx, settings.BatterySetting, 10))
+	require.Error(t, cli.SetSetting(ctx, settings.VignetteSetting, "On"))
+}
+
+func TestServerVideoRecord(t *testing.T) {
+	srv, cli := newCamera(t)
+
+	ctx := context.Background()
+	f, err := cli.VideoRecord(ctx, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.Equal(t, zcamtest.DefaultFolder, f.Folder())
+	require.False(t, srv.Camera.IsRecording())
+
+	require.NoError(t, f.Open(ctx, zcam.Original))
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	require.Len(t, data, 1024)
+}
+
+func TestServerVideoRecordSplit(t *testing.T) {
+	srv, cli := newCamera(t)
+	srv.Camera.SetValue(settings.SplitDurationSetting, "5")
+	srv.Camera.SetValue(settings.RecProxyFileSetting, "On")
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	srv.Camera.Now = func() time.Time { return now }
+
+	ctx := context.Background()
+	require.NoError(t, cli.StartVideoRecord(ctx))
+	require.Equal(t, zcamtest.ModeRecording, srv.Camera.Mode())
+
+	now = now.Add(12 * time.Minute)
+	require.NoError(t, cli.StopVideoRecord(ctx))
+
+	files := srv.Camera.Files(zcamtest.DefaultFolder)
+	require.Len(t, files, 6)
+	require.Equal(t, "ZCAM0001_0002_202401011200.MOV", files[4].Name)
+	require.Equal(t, 2*60*1000, files[4].Duration)
+	require.Equal(t, "ZCAM0001_0002_202401011200_proxy.MOV", files[5].Name)
+}
+
+func TestServerCard(t *testing.T) {
+	srv, cli := newCamera(t)
+
+	ctx := context.Background()
+	_, err := cli.CaptureStill(ctx)
+	require.NoError(t, err)
+	require.Len(t, srv.Camera.Files(zcamtest.DefaultFolder), 1)
+
+	require.NoError(t, cli.FormatCard(ctx))
+	require.Len(t, srv.Camera.Files(zcamtest.DefaultFolder), 0)
+
+	srv.Camera.SetCardPresent(false)
+	present, err := cli.CheckCardPresence(ctx)
+	require.NoError(t, err)
+	require.False(t, present)
+
+	require.Error(t, cli.StartVideoRecord(ctx))
+}
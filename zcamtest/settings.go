@@ -0,0 +1,184 @@
+package zcamtest
+
+import (
+	"github.com/mcuadros/go-zcam-e2/settings"
+)
+
+// SettingType mirrors the type field returned by the /ctrl/get endpoint.
+type SettingType int
+
+const (
+	ChoiceSetting SettingType = 1
+	RangeSetting  SettingType = 2
+	StringSetting SettingType = 3
+)
+
+// Setting is the emulated state of a single camera setting. Value holds a
+// string for choice and string settings, and an int for range settings.
+type Setting struct {
+	Key      settings.Setting
+	Type     SettingType
+	ReadOnly bool
+	Value    any
+	Options  []string
+	Min      int
+	Max      int
+	Step     int
+}
+
+func (s *Setting) clone() *Setting {
+	c := *s
+	c.Options = append([]string(nil), s.Options...)
+	return &c
+}
+
+func choice(key settings.Setting, value string, opts ...string) *Setting {
+	return &Setting{Key: key, Type: ChoiceSetting, Value: value, Options: opts}
+}
+
+func rangeOf(key settings.Setting, value, min, max, step int) *Setting {
+	return &Setting{Key: key, Type: RangeSetting, Value: value, Min: min, Max: max, Step: step}
+}
+
+func str(key settings.Setting, value string) *Setting {
+	return &Setting{Key: key, Type: StringSetting, Value: value}
+}
+
+func readOnly(s *Setting) *Setting {
+	s.ReadOnly = true
+	return s
+}
+
+var onOff = []string{"Off", "On"}
+
+var fps = []string{"23.98", "24", "25", "29.97", "30", "50", "59.94", "60"}
+
+// defaultSettings returns the factory configuration of an E2, photo settings
+// and the vignette are not supported by the E2 and so they are not present.
+func defaultSettings() map[settings.Setting]*Setting {
+	list := []*Setting{
+		// Video
+		choice(settings.MovFmtSetting, "4KP29.97",
+			"4KP23.98", "4KP24", "4KP25", "4KP29.97", "4KP30", "4KP50", "4KP59.94", "4KP60",
+			"C4KP24", "C4KP25", "C4KP30", "1080P60", "1080P120"),
+		choice(settings.ResolutionSetting, "4K", "C4K", "4K", "4K (Low Noise)", "1920x1080"),
+		choice(settings.ProjectFPSSetting, "29.97", fps...),
+		choice(settings.RecordFileFormatSetting, "MOV", "MOV", "MP4"),
+		choice(settings.RecProxyFileSetting, "Off", onOff...),
+		choice(settings.VideoEncoderSetting, "H.264", "H.264", "H.265", "ProRes"),
+		choice(settings.SplitDurationSetting, "Off", "Off", "1", "5", "10", "15", "30"),
+		choice(settings.BitrateLevelSetting, "high", "low", "medium", "high"),
+		choice(settings.ComposeModeSetting, "Normal", "Normal", "WDR"),
+		choice(settings.MovVFRSetting, "Off", "Off", "30", "60", "90", "120", "150", "160"),
+		choice(settings.RecFPSSetting, "29.97", fps...),
+		rangeOf(settings.VideoTLIntervalSetting, 1, 1, 600, 1),
+		readOnly(choice(settings.EnableVideoTLSetting, "1", "0", "1")),
+		readOnly(rangeOf(settings.RecDurationSetting, 0, 0, 86400, 1)),
+		readOnly(str(settings.LastFileNameSetting, "")),
+
+		// Focus & Zoom
+		choice(settings.FocusSetting, "AF", "AF", "MF"),
+		choice(settings.AFModeSetting, "Flexible Zone", "Flexible Zone", "Human Detection"),
+		rangeOf(settings.MFDriveSetting, 0, -3, 3, 1),
+		choice(settings.LensZoomSetting, "stop", "in", "out", "stop"),
+		choice(settings.OISModeSetting, "Off", onOff...),
+		choice(settings.AFLockSetting, "Off", onOff...),
+		rangeOf(settings.LensZoomPosSetting, 0, 0, 100, 1),
+		rangeOf(settings.LensFocusPosSetting, 0, 0, 1000, 1),
+		rangeOf(settings.LensFocusSpdSetting, 5, 1, 10, 1),
+		choice(settings.CAFSetting, "Off", onOff...),
+		choice(settings.CAFSensSetting, "Middle", "Low", "Middle", "High"),
+		choice(settings.LiveCAFSetting, "Off", onOff...),
+		choice(settings.MFMagSetting, "Off", onOff...),
+		choice(settings.RestoreLensPosSetting, "Off", onOff...),
+
+		// Exposure
+		choice(settings.MeterModeSetting, "Center", "Center", "Average", "Spot"),
+		choice(settings.MaxISOSetting, "12800", "1600", "3200", "6400", "12800", "25600"),
+		choice(settings.EVChoiceSetting, "0", "-3", "-2", "-1", "0", "1", "2", "3"),
+		choice(settings.ISOSetting, "Auto",
+			"Auto", "400", "500", "640", "800", "1000", "1250", "1600", "2000", "2500", "3200", "6400", "12800", "Max ISO"),
+		choice(settings.IrisSetting, "2.8", "1.4", "2", "2.8", "4", "5.6", "8", "11", "16"),
+		choice(settings.ShutterAngleSetting, "180", "Auto", "45", "90", "172.8", "180", "270", "360"),
+		choice(settings.MaxExpShutterAngleSetting, "360", "180", "270", "360"),
+		choice(settings.ShutterTimeSetting, "Auto", "Auto", "1/50", "1/60", "1/100", "1/120", "1/250", "1/500"),
+		choice(settings.MaxExpShutterTimeSetting, "1/30", "1/30", "1/60", "1/120"),
+		choice(settings.ShtOperationSetting, "Angle", "Speed", "Angle"),
+		choice(settings.DualISOSetting, "Auto", "Auto", "Low", "High"),
+		choice(settings.AEFreezeSetting, "Off", onOff...),
+		readOnly(str(settings.LiveAEFNoSetting, "2.8")),
+		readOnly(str(settings.LiveAEISOSetting, "800")),
+		readOnly(str(settings.LiveAEShutterSetting, "1/60")),
+		readOnly(str(settings.LiveAEShutterAngleSetting, "180")),
+
+		// White Balance
+		choice(settings.WBSetting, "Auto", "Auto", "Manual"),
+		rangeOf(settings.MWBSetting, 5600, 2300, 10000, 100),
+		rangeOf(settings.TintSetting, 0, -100, 100, 1),
+		choice(settings.WBPrioritySetting, "Ambiance", "Ambiance", "White"),
+		rangeOf(settings.MWBRSetting, 256, 0, 1023, 1),
+		rangeOf(settings.MWBGSetting, 256, 0, 1023, 1),
+		rangeOf(settings.MWBBSetting, 256, 0, 1023, 1),
+
+		// Image
+		choice(settings.SharpnessSetting, "Normal", "Strong", "Normal", "Weak"),
+		rangeOf(settings.ContrastSetting, 50, 0, 100, 1),
+		rangeOf(settings.SaturationSetting, 50, 0, 100, 1),
+		rangeOf(settings.BrightnessSetting, 50, 0, 100, 1),
+		choice(settings.LUTSetting, "rec709", "rec709", "zlog"),
+		choice(settings.LumaLevelSetting, "0-255", "0-255", "16-235"),
+
+		// Stream
+		choice(settings.SendStreamSetting, "stream1", "stream0", "stream1"),
+
+		// Audio
+		choice(settings.PrimaryAudioSetting, "AAC", "AAC", "PCM"),
+		choice(settings.AudioChannelSetting, "CH1 & CH2", "CH1 & CH2", "CH1", "CH2"),
+		rangeOf(settings.AudioInputGainSetting, 30, 0, 90, 1),
+		rangeOf(settings.AudioOutputGainSetting, 30, 0, 100, 1),
+		choice(settings.AudioPhantomPowerSetting, "Off", onOff...),
+		choice(settings.AINGainTypeSetting, "AGC", "AGC", "MGC"),
+
+		// Timecode
+		choice(settings.TCCountUpSetting, "free run", "free run", "record run"),
+		choice(settings.TCHDMIDisplaySetting, "Off", onOff...),
+		choice(settings.TCDropFrameSetting, "DF", "DF", "NDF"),
+
+		// Assist tool
+		choice(settings.AssistToolDisplaySetting, "Off", onOff...),
+		choice(settings.AssistToolPeakOnOffSetting, "Off", onOff...),
+		choice(settings.AssistToolPeakColorSetting, "Red", "Red", "Green", "Blue", "White"),
+		choice(settings.AssistToolExposureSetting, "Zebra", "Zebra", "False Color"),
+		rangeOf(settings.AssistToolZebraTH1Setting, 95, 0, 100, 1),
+		rangeOf(settings.AssistToolZebraTH2Setting, 80, 0, 100, 1),
+
+		// Misc
+		str(settings.SSIDSetting, "E2_329A0010009"),
+		choice(settings.FlickerSetting, "50Hz", "50Hz", "60Hz"),
+		choice(settings.VideoSystemSetting, "NTSC", "NTSC", "PAL", "CINEMA"),
+		choice(settings.WiFiSetting, "On", onOff...),
+		readOnly(rangeOf(settings.BatterySetting, 100, 0, 100, 1)),
+		readOnly(rangeOf(settings.BatteryVoltage, 168, 0, 200, 1)),
+		choice(settings.LEDSetting, "On", onOff...),
+		rangeOf(settings.LCDBacklightSetting, 50, 0, 100, 1),
+		choice(settings.HDMIFormatSetting, "Auto", "Auto", "4KP60", "4KP30", "1080P60", "1080P30"),
+		choice(settings.HDMIOSDSetting, "On", onOff...),
+		choice(settings.USBDeviceRoleSetting, "Network", "Host", "Mass storage", "Network"),
+		choice(settings.UARTRoleSetting, "Pelco D", "Pelco D", "Controller"),
+		choice(settings.AutoOffSetting, "Off", onOff...),
+		choice(settings.AutoOffLCDSetting, "Off", onOff...),
+		readOnly(str(settings.SerialNumberSetting, "329A0010009")),
+		choice(settings.DesqueezeSetting, "1x", "1x", "1.33x", "1.5x", "2x"),
+
+		// Multiple Camera
+		choice(settings.MultipleModeSetting, "single", "single", "master", "slave"),
+		rangeOf(settings.MultipleIDSetting, 1, 1, 255, 1),
+	}
+
+	m := make(map[settings.Setting]*Setting, len(list))
+	for _, s := range list {
+		m[s.Key] = s
+	}
+
+	return m
+}